@@ -1,10 +1,13 @@
 package env
 
 import (
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	units "github.com/docker/go-units"
 )
 
 var (
@@ -65,3 +68,147 @@ func GetEnvOrDefault[T EnvType](key string, defaultValue T) T {
 	}
 	return defaultValue
 }
+
+// GetOneOf returns the value of the first environment variable set among keys or the default value if none is set.
+// keys are checked in given order which allows migrating to a new name while still honoring legacy ones
+//
+//	GetOneOf([]string{"NEW_VAR", "OLD_VAR"}, "default")
+func GetOneOf(keys []string, defaultValue string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+// GetBytes returns the value of the environment variable as size in bytes or the default value if the variable is not set or invalid.
+// decimal (KB, MB, GB) and binary (KiB, MiB, GiB) suffixes are supported, bare numbers are bytes
+//
+//	MAX_BODY_SIZE=10MB  => 10000000
+//	MAX_BODY_SIZE=10MiB => 10485760
+func GetBytes(key string, defaultValue int64) int64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	parse := units.FromHumanSize
+	if strings.HasSuffix(strings.ToLower(value), "ib") {
+		parse = units.RAMInBytes
+	}
+	size, err := parse(value)
+	if err != nil {
+		return defaultValue
+	}
+	return size
+}
+
+// GetTime returns the value of the environment variable parsed with given layout or the default value if the variable is not set or invalid.
+// layout defaults to time.RFC3339 if empty and invalid values are logged as a warning
+//
+//	FEATURE_EXPIRES=2025-01-01T00:00:00Z
+func GetTime(key string, layout string, defaultValue time.Time) time.Time {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		slog.Warn("invalid time in environment variable, using default", "key", key, "value", value, "layout", layout, "error", err)
+		return defaultValue
+	}
+	return t
+}
+
+// GetExpanded returns the value of the environment variable with ${VAR} and $VAR references resolved or the default value if the variable is not set.
+// references are resolved recursively i.e values of referenced variables are expanded as well, unset (or self referencing) variables
+// expand to empty string and are logged as a warning
+//
+//	LOG_DIR=${HOME}/logs => /home/user/logs
+func GetExpanded(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return expand(key, value, map[string]struct{}{key: {}})
+}
+
+// expand resolves references in value of the key, seen holds the variables being expanded to break cycles
+func expand(key, value string, seen map[string]struct{}) string {
+	return os.Expand(value, func(name string) string {
+		ref, ok := os.LookupEnv(name)
+		if _, cyclic := seen[name]; !ok || cyclic {
+			slog.Warn("unresolved reference in environment variable, expanding to empty", "key", key, "reference", name)
+			return ""
+		}
+		seen[name] = struct{}{}
+		defer delete(seen, name)
+		return expand(name, ref, seen)
+	})
+}
+
+// Prefixed reads environment variables with a common prefix
+type Prefixed struct {
+	prefix string
+}
+
+// WithPrefix returns a Prefixed which prepends given prefix to all keys
+//
+//	env.WithPrefix("NUCLEI_").GetEnvOrDefault("RATE", "1") // reads NUCLEI_RATE
+func WithPrefix(prefix string) Prefixed {
+	return Prefixed{prefix: prefix}
+}
+
+// Key returns the prefixed key
+func (p Prefixed) Key(key string) string {
+	return p.prefix + key
+}
+
+// Getenv returns the value of the prefixed environment variable
+func (p Prefixed) Getenv(key string) string {
+	return os.Getenv(p.Key(key))
+}
+
+// GetEnvOrDefault returns the value of the prefixed environment variable or the default value if the variable is not set.
+func (p Prefixed) GetEnvOrDefault(key string, defaultValue string) string {
+	return GetEnvOrDefault(p.Key(key), defaultValue)
+}
+
+// GetInt returns the value of the prefixed environment variable as int or the default value if the variable is not set or invalid.
+func (p Prefixed) GetInt(key string, defaultValue int) int {
+	return GetEnvOrDefault(p.Key(key), defaultValue)
+}
+
+// GetBool returns the value of the prefixed environment variable as bool or the default value if the variable is not set or invalid.
+func (p Prefixed) GetBool(key string, defaultValue bool) bool {
+	return GetEnvOrDefault(p.Key(key), defaultValue)
+}
+
+// GetFloat returns the value of the prefixed environment variable as float64 or the default value if the variable is not set or invalid.
+func (p Prefixed) GetFloat(key string, defaultValue float64) float64 {
+	return GetEnvOrDefault(p.Key(key), defaultValue)
+}
+
+// GetDuration returns the value of the prefixed environment variable as time.Duration or the default value if the variable is not set or invalid.
+func (p Prefixed) GetDuration(key string, defaultValue time.Duration) time.Duration {
+	return GetEnvOrDefault(p.Key(key), defaultValue)
+}
+
+// GetBytes returns the value of the prefixed environment variable as size in bytes or the default value if the variable is not set or invalid.
+func (p Prefixed) GetBytes(key string, defaultValue int64) int64 {
+	return GetBytes(p.Key(key), defaultValue)
+}
+
+// GetTime returns the value of the prefixed environment variable parsed with given layout or the default value if the variable is not set or invalid.
+func (p Prefixed) GetTime(key string, layout string, defaultValue time.Time) time.Time {
+	return GetTime(p.Key(key), layout, defaultValue)
+}
+
+// GetExpanded returns the value of the prefixed environment variable with references resolved or the default value if the variable is not set.
+// referenced variables are not prefixed
+func (p Prefixed) GetExpanded(key string, defaultValue string) string {
+	return GetExpanded(p.Key(key), defaultValue)
+}
@@ -88,3 +88,154 @@ func TestGetEnvOrDefault(t *testing.T) {
 		t.Errorf("Expected 'default', got %s", resultDefault)
 	}
 }
+
+func TestWithPrefix(t *testing.T) {
+	_ = os.Setenv("NUCLEI_RATE", "150")
+	_ = os.Setenv("NUCLEI_TIMEOUT", "5s")
+	defer func() {
+		_ = os.Unsetenv("NUCLEI_RATE")
+		_ = os.Unsetenv("NUCLEI_TIMEOUT")
+	}()
+
+	p := WithPrefix("NUCLEI_")
+	if got := p.GetEnvOrDefault("RATE", "1"); got != "150" {
+		t.Errorf("Expected '150', got %s", got)
+	}
+	if got := p.GetInt("RATE", 1); got != 150 {
+		t.Errorf("Expected 150, got %d", got)
+	}
+	if got := p.GetDuration("TIMEOUT", time.Second); got != 5*time.Second {
+		t.Errorf("Expected 5s, got %s", got)
+	}
+	if got := p.GetEnvOrDefault("NON_EXISTING", "default"); got != "default" {
+		t.Errorf("Expected 'default', got %s", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	_ = os.Setenv("TEST_WATCH", "info")
+	defer func() {
+		_ = os.Unsetenv("TEST_WATCH")
+	}()
+
+	type change struct{ old, new string }
+	changes := make(chan change, 1)
+	w := WatchWithInterval("TEST_WATCH", 10*time.Millisecond, func(old, new string) {
+		changes <- change{old, new}
+	})
+	defer w.Stop()
+
+	_ = os.Setenv("TEST_WATCH", "debug")
+	select {
+	case c := <-changes:
+		if c.old != "info" || c.new != "debug" {
+			t.Errorf("Expected info -> debug, got %s -> %s", c.old, c.new)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected callback to be invoked")
+	}
+
+	w.Stop()
+	w.Stop()
+}
+
+func TestGetBytes(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{"", 42},
+		{"1024", 1024},
+		{"10B", 10},
+		{"10KB", 10 * 1000},
+		{"10MB", 10 * 1000 * 1000},
+		{"1GB", 1000 * 1000 * 1000},
+		{"10KiB", 10 * 1024},
+		{"10MiB", 10 * 1024 * 1024},
+		{"1GiB", 1024 * 1024 * 1024},
+		{"1.5 MB", 1500 * 1000},
+		{"10XB", 42},
+		{"invalid", 42},
+	}
+	for _, tt := range tests {
+		_ = os.Setenv("MAX_BODY_SIZE", tt.value)
+		if got := GetBytes("MAX_BODY_SIZE", 42); got != tt.expected {
+			t.Errorf("GetBytes(%q): expected %d, got %d", tt.value, tt.expected, got)
+		}
+	}
+	_ = os.Unsetenv("MAX_BODY_SIZE")
+}
+
+func TestGetTime(t *testing.T) {
+	def := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() {
+		_ = os.Unsetenv("FEATURE_EXPIRES")
+	}()
+
+	_ = os.Setenv("FEATURE_EXPIRES", "2025-01-01T00:00:00Z")
+	if got := GetTime("FEATURE_EXPIRES", "", def); !got.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2025-01-01T00:00:00Z, got %s", got)
+	}
+
+	_ = os.Setenv("FEATURE_EXPIRES", "2025-01-01")
+	if got := GetTime("FEATURE_EXPIRES", "", def); !got.Equal(def) {
+		t.Errorf("Expected default for malformed value, got %s", got)
+	}
+	if got := GetTime("FEATURE_EXPIRES", time.DateOnly, def); !got.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2025-01-01 with custom layout, got %s", got)
+	}
+
+	_ = os.Unsetenv("FEATURE_EXPIRES")
+	if got := GetTime("FEATURE_EXPIRES", "", def); !got.Equal(def) {
+		t.Errorf("Expected default for unset value, got %s", got)
+	}
+}
+
+func TestGetOneOf(t *testing.T) {
+	keys := []string{"NEW_VAR", "OLD_VAR"}
+	_ = os.Unsetenv("NEW_VAR")
+	_ = os.Unsetenv("OLD_VAR")
+
+	if got := GetOneOf(keys, "default"); got != "default" {
+		t.Errorf("expected default, got %q", got)
+	}
+
+	_ = os.Setenv("OLD_VAR", "old")
+	if got := GetOneOf(keys, "default"); got != "old" {
+		t.Errorf("expected old, got %q", got)
+	}
+
+	_ = os.Setenv("NEW_VAR", "new")
+	if got := GetOneOf(keys, "default"); got != "new" {
+		t.Errorf("expected new to take precedence, got %q", got)
+	}
+
+	_ = os.Unsetenv("NEW_VAR")
+	_ = os.Unsetenv("OLD_VAR")
+}
+
+func TestGetExpanded(t *testing.T) {
+	t.Setenv("EXPAND_HOME", "/home/user")
+	t.Setenv("EXPAND_DATA", "${EXPAND_HOME}/data")
+	t.Setenv("EXPAND_LOGS", "$EXPAND_DATA/logs")
+	t.Setenv("EXPAND_MISSING", "${EXPAND_UNSET}/logs")
+	t.Setenv("EXPAND_CYCLE", "${EXPAND_CYCLE}/logs")
+	_ = os.Unsetenv("EXPAND_UNSET")
+
+	tests := map[string]string{
+		"EXPAND_DATA":    "/home/user/data",
+		"EXPAND_LOGS":    "/home/user/data/logs",
+		"EXPAND_MISSING": "/logs",
+		"EXPAND_CYCLE":   "/logs",
+		"EXPAND_UNSET":   "default",
+	}
+	for key, expected := range tests {
+		if got := GetExpanded(key, "default"); got != expected {
+			t.Errorf("%s: expected %q, got %q", key, expected, got)
+		}
+	}
+
+	if got := WithPrefix("EXPAND_").GetExpanded("LOGS", "default"); got != "/home/user/data/logs" {
+		t.Errorf("expected prefixed value to be expanded, got %q", got)
+	}
+}
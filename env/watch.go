@@ -0,0 +1,63 @@
+package env
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the default polling interval used by Watch
+var DefaultWatchInterval = time.Second
+
+// Watcher polls an environment variable and notifies on changes
+type Watcher struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Watch polls the environment variable every DefaultWatchInterval and
+// invokes onChange when its value changes, it only catches changes made
+// to the environment of the current process (ex: os.Setenv) since the
+// environment of a running process can not be changed externally
+//
+//	w := env.Watch("LOG_LEVEL", func(old, new string) {
+//		setLogLevel(new)
+//	})
+//	defer w.Stop()
+func Watch(key string, onChange func(old, new string)) *Watcher {
+	return WatchWithInterval(key, DefaultWatchInterval, onChange)
+}
+
+// WatchWithInterval is like Watch but polls with given interval
+func WatchWithInterval(key string, interval time.Duration, onChange func(old, new string)) *Watcher {
+	w := &Watcher{stop: make(chan struct{})}
+	current := os.Getenv(key)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				value := os.Getenv(key)
+				if value != current {
+					old := current
+					current = value
+					onChange(old, value)
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+// Stop stops watching the environment variable
+// it is safe to call Stop multiple times
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
@@ -0,0 +1,178 @@
+package errkit
+
+import (
+	"log/slog"
+	"net"
+	"net/url"
+	"time"
+)
+
+const (
+	// RequestIDKey is the attribute key used to store the request id
+	// used to correlate errors across components
+	RequestIDKey = "request_id"
+	// ElapsedKey is the attribute key used to store the duration
+	// of the failed operation
+	ElapsedKey = "elapsed"
+)
+
+// addrValue keeps net.Addr typed in attributes
+// while rendering it as its string form
+type addrValue struct {
+	net.Addr
+}
+
+// LogValue implements slog.LogValuer
+func (a addrValue) LogValue() slog.Value {
+	return slog.StringValue(a.String())
+}
+
+// urlValue keeps *url.URL typed in attributes
+// while rendering it as its redacted string form
+type urlValue struct {
+	*url.URL
+}
+
+// String returns the url with password redacted
+func (u urlValue) String() string {
+	return u.Redacted()
+}
+
+// LogValue implements slog.LogValuer
+func (u urlValue) LogValue() slog.Value {
+	return slog.StringValue(u.String())
+}
+
+// Annotate adds given key value pair as an attribute and returns the error
+// for chaining, like SetAttr attributes are unique by key i.e the pair is
+// ignored if an attribute with same key already exists
+//
+//	Example:
+//
+//	myError.Annotate("host", host).Annotate("attempt", n)
+func (e *ErrorX) Annotate(key string, value any) *ErrorX {
+	if _, ok := e.Attr(key); ok {
+		return e
+	}
+	e.init()
+	e.record.AddAttrs(slog.Any(key, value))
+	return e
+}
+
+// SetAddr adds given network address as an attribute
+// it is rendered as the address string ex: 10.0.0.1:80
+//
+//	Example:
+//
+//	myError.SetAddr("remote", conn.RemoteAddr())
+func (e *ErrorX) SetAddr(key string, addr net.Addr) *ErrorX {
+	if addr == nil {
+		return e
+	}
+	e.init()
+	e.record.Add(slog.Any(key, addrValue{addr}))
+	return e
+}
+
+// SetURL adds given url as an attribute
+// it is rendered as the url string with password redacted
+//
+//	Example:
+//
+//	myError.SetURL("url", req.URL)
+func (e *ErrorX) SetURL(key string, u *url.URL) *ErrorX {
+	if u == nil {
+		return e
+	}
+	e.init()
+	e.record.Add(slog.Any(key, urlValue{u}))
+	return e
+}
+
+// SetRequestID sets the request id of the error under RequestIDKey
+// replacing the existing one, while merging errors the first
+// non-empty request id is kept
+//
+//	Example:
+//
+//	myError.SetRequestID(req.Header.Get("X-Request-ID"))
+func (e *ErrorX) SetRequestID(id string) *ErrorX {
+	if id == "" {
+		return e
+	}
+	e.setAttr(slog.String(RequestIDKey, id))
+	return e
+}
+
+// RequestID returns the request id of the error or empty string if not set
+func (e *ErrorX) RequestID() string {
+	if a, ok := e.Attr(RequestIDKey); ok {
+		return a.Value.String()
+	}
+	return ""
+}
+
+// SetElapsed records how long the failed operation took under ElapsedKey
+// replacing the existing one, it can be used by retry and backoff logic
+//
+//	Example:
+//
+//	start := time.Now()
+//	if err := op(); err != nil {
+//		return errkit.FromError(err).SetElapsed(time.Since(start))
+//	}
+func (e *ErrorX) SetElapsed(d time.Duration) *ErrorX {
+	e.setAttr(slog.Duration(ElapsedKey, d))
+	return e
+}
+
+// Since records the time elapsed since start, it is shorthand
+// for SetElapsed(time.Since(start))
+func (e *ErrorX) Since(start time.Time) *ErrorX {
+	return e.SetElapsed(time.Since(start))
+}
+
+// Elapsed returns the duration recorded by SetElapsed if any
+func (e *ErrorX) Elapsed() (time.Duration, bool) {
+	if a, ok := e.Attr(ElapsedKey); ok && a.Value.Kind() == slog.KindDuration {
+		return a.Value.Duration(), true
+	}
+	return 0, false
+}
+
+// Attr returns the first attribute with given key and whether it is present
+//
+//	Example:
+//
+//	if a, ok := myError.Attr("address"); ok {
+//		fmt.Println(a.Value)
+//	}
+func (e *ErrorX) Attr(key string) (slog.Attr, bool) {
+	var found slog.Attr
+	var ok bool
+	if e.record != nil {
+		e.record.Attrs(func(a slog.Attr) bool {
+			if a.Key == key {
+				found, ok = a, true
+				return false
+			}
+			return true
+		})
+	}
+	return found, ok
+}
+
+// setAttr adds given attribute replacing existing ones with same key
+func (e *ErrorX) setAttr(attr slog.Attr) {
+	e.init()
+	if _, ok := e.Attr(attr.Key); ok {
+		attrs := e.Attrs()
+		*e.record = slog.NewRecord(e.record.Time, e.record.Level, e.record.Message, e.record.PC)
+		for _, a := range attrs {
+			if a.Key != attr.Key {
+				e.record.AddAttrs(a)
+			}
+		}
+	}
+	e.record.AddAttrs(attr)
+}
@@ -0,0 +1,44 @@
+package errkit
+
+import "sync"
+
+// Collector gathers errors from multiple goroutines and merges
+// them into a single ErrorX, it is safe for concurrent use
+//
+//	var c errkit.Collector
+//	for _, job := range jobs {
+//		go func() { c.Add(run(job)) }()
+//	}
+//	return c.Err().ErrorOrNil()
+type Collector struct {
+	mu sync.Mutex
+	x  *ErrorX
+}
+
+// Add adds given error to the collector
+// nil errors are ignored
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.x == nil {
+		c.x = &ErrorX{}
+		c.x.init()
+	}
+	parseError(c.x, err)
+}
+
+// Err returns merged error of all collected errors
+// or nil if no errors were collected
+// Note: use ErrorOrNil to return it as error since a nil *ErrorX
+// stored in an error interface is not a nil error
+func (c *Collector) Err() *ErrorX {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.x == nil || len(c.x.errs) == 0 {
+		return nil
+	}
+	return c.x.clone()
+}
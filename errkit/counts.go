@@ -0,0 +1,68 @@
+package errkit
+
+import (
+	"maps"
+	"sync"
+)
+
+// Counts is the process wide counter of errors by kind incremented by Observe
+var Counts = &KindCounts{}
+
+// KindCounts counts errors by the id of their kind, it is a zero dependency
+// way to see the distribution of error kinds, it is safe for concurrent use
+type KindCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// Observe counts given error under the id of its kind, errors without kind
+// are classified the same way as FromError does and nil errors are ignored
+func (c *KindCounts) Observe(err error) {
+	if err == nil {
+		return
+	}
+	x := &ErrorX{}
+	parseError(x, err)
+	kind := x.Kind().String()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[kind]++
+}
+
+// Snapshot returns a copy of the counts by kind id
+// combined kinds are counted under their combined id ex: "network-temporary-error,deadline-error"
+func (c *KindCounts) Snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := make(map[string]int64, len(c.counts))
+	maps.Copy(snapshot, c.counts)
+	return snapshot
+}
+
+// Reset clears all counts
+func (c *KindCounts) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.counts)
+}
+
+// Observe counts given error in Counts
+//
+//	Example:
+//
+//	if err != nil {
+//		errkit.Observe(err)
+//	}
+//	log.Println(errkit.Counts.Snapshot())
+func Observe(err error) {
+	Counts.Observe(err)
+}
+
+// ResetCounts clears all counts of Counts
+func ResetCounts() {
+	Counts.Reset()
+}
@@ -0,0 +1,90 @@
+package errkit
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Diff returns a human readable diff between two errors
+// it reports difference in kind, error messages present in only one of
+// the errors and attribute changes, it returns empty string if both are equal
+//
+//	kind: network-permanent-error != network-temporary-error
+//	errors:
+//	  - only in a
+//	  + only in b
+//	attrs:
+//	  ~ port: 80 != 443
+func Diff(a, b *ErrorX) string {
+	switch {
+	case a == nil && b == nil:
+		return ""
+	case a == nil:
+		return "a is nil"
+	case b == nil:
+		return "b is nil"
+	}
+
+	var sb strings.Builder
+	if kindA, kindB := a.Kind().String(), b.Kind().String(); kindA != kindB {
+		_, _ = fmt.Fprintf(&sb, "kind: %s != %s\n", kindA, kindB)
+	}
+
+	msgsA, msgsB := errorMessages(a), errorMessages(b)
+	var errDiff []string
+	for _, msg := range msgsA {
+		if !slices.Contains(msgsB, msg) {
+			errDiff = append(errDiff, "  - "+msg)
+		}
+	}
+	for _, msg := range msgsB {
+		if !slices.Contains(msgsA, msg) {
+			errDiff = append(errDiff, "  + "+msg)
+		}
+	}
+	if len(errDiff) > 0 {
+		sb.WriteString("errors:\n")
+		sb.WriteString(strings.Join(errDiff, "\n"))
+		sb.WriteString("\n")
+	}
+
+	attrsA, attrsB := a.sortedAttrs(), b.sortedAttrs()
+	valuesB := make(map[string]string, len(attrsB))
+	for _, attr := range attrsB {
+		valuesB[attr.Key] = attr.Value.String()
+	}
+	var attrDiff []string
+	seen := make(map[string]struct{}, len(attrsA))
+	for _, attr := range attrsA {
+		seen[attr.Key] = struct{}{}
+		valueA := attr.Value.String()
+		valueB, ok := valuesB[attr.Key]
+		switch {
+		case !ok:
+			attrDiff = append(attrDiff, fmt.Sprintf("  - %s=%s", attr.Key, valueA))
+		case valueA != valueB:
+			attrDiff = append(attrDiff, fmt.Sprintf("  ~ %s: %s != %s", attr.Key, valueA, valueB))
+		}
+	}
+	for _, attr := range attrsB {
+		if _, ok := seen[attr.Key]; !ok {
+			attrDiff = append(attrDiff, fmt.Sprintf("  + %s=%s", attr.Key, attr.Value.String()))
+		}
+	}
+	if len(attrDiff) > 0 {
+		sb.WriteString("attrs:\n")
+		sb.WriteString(strings.Join(attrDiff, "\n"))
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func errorMessages(x *ErrorX) []string {
+	msgs := make([]string, 0, len(x.errs))
+	for _, err := range x.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
@@ -3,14 +3,21 @@
 package errkit
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"reflect"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/projectdiscovery/utils/env"
 )
@@ -28,6 +35,9 @@ const (
 	MultiLineErrPrefix = "the following errors occurred:"
 	// Space is the identifier used for indentation
 	Space = " "
+
+	// deadlineAttrKey is the attribute key of the deadline set with SetDeadline
+	deadlineAttrKey = "deadline"
 )
 
 var (
@@ -38,20 +48,34 @@ var (
 	ErrFieldSeparator = env.GetEnvOrDefault("ERR_FIELD_SEPERATOR", Space)
 	// ErrChainSeperator
 	ErrChainSeperator = env.GetEnvOrDefault("ERR_CHAIN_SEPERATOR", DelimSemiColon)
+	// KindSeparator is used to join ids of combined error kinds (see CombineErrKinds)
+	// and to split them in ParseKind
+	KindSeparator = env.GetEnvOrDefault("ERR_KIND_SEPARATOR", ",")
 	// EnableTimestamp controls whether error timestamps are included
 	EnableTimestamp = env.GetEnvOrDefault("ENABLE_ERR_TIMESTAMP", false)
 	// EnableTrace controls whether error stack traces are included
 	EnableTrace = env.GetEnvOrDefault("ENABLE_ERR_TRACE", false)
+	// DisableDelimiterSplitting controls whether error messages are split on known delimiters
+	// when enabled error messages containing delimiters are treated as a single error
+	DisableDelimiterSplitting = env.GetEnvOrDefault("DISABLE_ERR_DELIM_SPLITTING", false)
+	// EnableKindTrace controls whether contributions to the error kind are recorded
+	// see ErrorX.KindContributions
+	EnableKindTrace = env.GetEnvOrDefault("ENABLE_ERR_KIND_TRACE", false)
 )
 
 // ErrorX is a custom error type that can handle all known types of errors
 // wrapping and joining strategies including custom ones and it supports error class
 // which can be shown to client/users in more meaningful way
 type ErrorX struct {
-	kind   ErrKind
-	record *slog.Record
-	source *slog.Source
-	errs   []error
+	kind        ErrKind
+	severity    slog.Level
+	hasSeverity bool
+	record      *slog.Record
+	source      *slog.Source
+	errs        []error
+	kindTrace   map[ErrKind][]string
+	// dropped is the number of errors not kept due to MaxErrorDepth
+	dropped int
 }
 
 func (e *ErrorX) init(skipStack ...int) {
@@ -62,29 +86,37 @@ func (e *ErrorX) init(skipStack ...int) {
 			e.record.Time = time.Now()
 		}
 		if EnableTrace {
-			// get fn name
-			var pcs [1]uintptr
 			// skip [runtime.Callers, ErrorX.init, parent]
 			skip := 3
 			if len(skipStack) > 0 {
 				skip = skipStack[0]
 			}
-			runtime.Callers(skip, pcs[:])
-			pc := pcs[0]
-			fs := runtime.CallersFrames([]uintptr{pc})
-			f, _ := fs.Next()
-			e.source = &slog.Source{
-				Function: f.Function,
-				File:     f.File,
-				Line:     f.Line,
-			}
+			// account for callerSource frame
+			e.source = callerSource(skip + 1)
 		}
 	}
 }
 
+// callerSource returns the source location of the caller
+// skip is number of frames to skip including runtime.Callers
+func callerSource(skip int) *slog.Source {
+	// get fn name
+	var pcs [1]uintptr
+	runtime.Callers(skip, pcs[:])
+	pc := pcs[0]
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+	return &slog.Source{
+		Function: f.Function,
+		File:     f.File,
+		Line:     f.Line,
+	}
+}
+
 // append is internal method to append given
 // error to error slice , it removes duplicates
 // earlier it used map which causes more allocations that necessary
+// duplicates are dropped in place so first-seen order is preserved
 func (e *ErrorX) append(errs ...error) {
 	for _, nerr := range errs {
 		found := false
@@ -101,6 +133,70 @@ func (e *ErrorX) append(errs ...error) {
 	}
 }
 
+// appendString is like append but only allocates
+// a new error if given message is not a duplicate
+func (e *ErrorX) appendString(msg string) {
+	if e.contains(msg) {
+		return
+	}
+	e.errs = append(e.errs, errors.New(msg))
+}
+
+// contains checks if an error with given message was already appended
+func (e *ErrorX) contains(msg string) bool {
+	for _, oerr := range e.errs {
+		if oerr.Error() == msg {
+			return true
+		}
+	}
+	return false
+}
+
+// drop records given error as dropped due to MaxErrorDepth
+// duplicates of kept errors are not counted
+func (e *ErrorX) drop(err error) {
+	switch v := err.(type) {
+	case *ErrorX:
+		e.dropped += len(v.errs) + v.dropped
+	case MultiError:
+		e.dropped += len(v.WrappedErrors())
+	case JoinedError:
+		e.dropped += len(v.Unwrap())
+	default:
+		if !e.contains(err.Error()) {
+			e.dropped++
+		}
+	}
+}
+
+// Truncated returns true if errors were dropped since the
+// error already contained MaxErrorDepth errors
+func (e *ErrorX) Truncated() bool {
+	return e.dropped > 0
+}
+
+// SetTimestamp sets the time at which the error occurred, unless set
+// explicitly it is the creation time of the error if EnableTimestamp
+// is set, errors parsed into another error keep the earliest timestamp
+//
+//	Example:
+//
+//	myError.SetTimestamp(resp.Date)
+func (e *ErrorX) SetTimestamp(t time.Time) *ErrorX {
+	e.init()
+	e.record.Time = t
+	return e
+}
+
+// Timestamp returns the time at which the error occurred
+// and false if the error does not have a timestamp
+func (e *ErrorX) Timestamp() (time.Time, bool) {
+	if e.record == nil || e.record.Time.IsZero() {
+		return time.Time{}, false
+	}
+	return e.record.Time, true
+}
+
 func (e ErrorX) MarshalJSON() ([]byte, error) {
 	tmp := []string{}
 	for _, err := range e.errs {
@@ -114,15 +210,67 @@ func (e ErrorX) MarshalJSON() ([]byte, error) {
 		"errors": tmp,
 	}
 	if e.record != nil && e.record.NumAttrs() > 0 {
-		m["attrs"] = slog.GroupValue(e.Attrs()...)
+		m["attrs"] = attrsToMap(e.sortedAttrs())
 	}
 	if e.source != nil {
 		m["source"] = e.source
 	}
+	if t, ok := e.Timestamp(); ok {
+		m["timestamp"] = t.Format(time.RFC3339)
+	}
 	return json.Marshal(m)
 }
 
+// LogValue implements slog.LogValuer
+// the error is logged as a group of ToSlogAttrs and its attributes
+// and includes the location where the error was created as source
+// if EnableTrace was set at the time
+func (e *ErrorX) LogValue() slog.Value {
+	return slog.GroupValue(append(ToSlogAttrs(e), e.sortedAttrs()...)...)
+}
+
+// LogEach emits one record per error to given logger at the severity of the error
+// each record carries the kind and attributes shared by all errors along with
+// the message of that error, a nil logger uses slog.Default()
+//
+//	Example:
+//
+//	x.LogEach(logger, "scan failed")
+//	// level=ERROR msg="scan failed" kind=network-temporary-error error="i/o timeout" host=example.com
+func (e *ErrorX) LogEach(logger *slog.Logger, msg string) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := e.Severity()
+	attrs := e.sortedAttrs()
+	for _, err := range e.errs {
+		args := make([]slog.Attr, 0, len(attrs)+2)
+		if kind := e.Kind(); kind != nil && kind.String() != "" {
+			args = append(args, slog.String("kind", kind.String()))
+		}
+		args = append(args, slog.String("error", err.Error()))
+		logger.LogAttrs(context.Background(), level, msg, append(args, attrs...)...)
+	}
+}
+
+// MarshalBatch writes given errors to w as JSON lines (NDJSON)
+// i.e one JSON object per line, nil errors are skipped
+func MarshalBatch(w io.Writer, errs ...*ErrorX) error {
+	enc := json.NewEncoder(w)
+	for _, x := range errs {
+		if x == nil {
+			continue
+		}
+		if err := enc.Encode(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Errors returns all errors parsed by the error
+// errors are returned in first-seen order with duplicates removed
+// i.e for joined errors it follows the order of Unwrap()
 func (e *ErrorX) Errors() []error {
 	return e.errs
 }
@@ -140,18 +288,153 @@ func (e *ErrorX) Attrs() []slog.Attr {
 	return values
 }
 
+// sortedAttrs returns all attributes sorted by key
+// this keeps rendered output deterministic irrespective of insertion order
+func (e *ErrorX) sortedAttrs() []slog.Attr {
+	values := e.Attrs()
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+	return values
+}
+
+// attrsToMap converts given attributes to a map that can be marshalled
+// since slog.Value does not implement json.Marshaler
+func attrsToMap(attrs []slog.Attr) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindGroup:
+			m[a.Key] = attrsToMap(v.Group())
+		default:
+			if err, ok := v.Any().(error); ok {
+				m[a.Key] = err.Error()
+			} else {
+				m[a.Key] = v.Any()
+			}
+		}
+	}
+	return m
+}
+
+// ToMap returns the error as a flat map for structured sinks
+// it contains kind, severity, error messages and source (if traced)
+// attributes are stored at top level with keys of nested groups joined
+// by "." and they do not override any of the above keys
+//
+//	{"kind": "deadline-error", "severity": "ERROR", "errors": ["..."], "req.url": "..."}
+func (e *ErrorX) ToMap() map[string]any {
+	m := map[string]any{}
+	flattenAttrs(m, "", e.sortedAttrs())
+	m["kind"] = e.Kind().String()
+	if level := e.Severity(); level == LevelFatal {
+		m["severity"] = "FATAL"
+	} else {
+		m["severity"] = level.String()
+	}
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	m["errors"] = msgs
+	if e.source != nil {
+		m["source"] = fmt.Sprintf("%s:%d", e.source.File, e.source.Line)
+	}
+	return m
+}
+
+// flattenAttrs stores given attributes in m with keys prefixed by prefix
+func flattenAttrs(m map[string]any, prefix string, attrs []slog.Attr) {
+	for _, a := range attrs {
+		key := prefix + a.Key
+		v := a.Value.Resolve()
+		switch v.Kind() {
+		case slog.KindGroup:
+			flattenAttrs(m, key+".", v.Group())
+		default:
+			if err, ok := v.Any().(error); ok {
+				m[key] = err.Error()
+			} else {
+				m[key] = v.Any()
+			}
+		}
+	}
+}
+
 // Build returns the object as error interface
 func (e *ErrorX) Build() error {
 	return e
 }
 
+// ErrorOrNil returns the error as error interface if it contains any error
+// and nil otherwise, returning an empty or nil *ErrorX directly would result
+// in a non nil error interface
+//
+//	Example:
+//
+//	agg := &errkit.ErrorX{}
+//	for _, item := range items {
+//		agg.Add(validate(item))
+//	}
+//	return agg.ErrorOrNil()
+func (e *ErrorX) ErrorOrNil() error {
+	if e == nil || len(e.errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Into sets the error pointed by target to the error if it contains any error
+// and leaves it unchanged otherwise, an error already set in target is not
+// overwritten (see IntoOverwrite) so that it finalizes the returned error of
+// a function when deferred
+//
+//	Example:
+//
+//	func cleanup(paths []string) (err error) {
+//		agg := &errkit.ErrorX{}
+//		defer agg.Into(&err)
+//		for _, path := range paths {
+//			agg.Add(os.Remove(path))
+//		}
+//		return nil
+//	}
+func (e *ErrorX) Into(target *error) {
+	if target == nil || *target != nil {
+		return
+	}
+	if err := e.ErrorOrNil(); err != nil {
+		*target = err
+	}
+}
+
+// IntoOverwrite is like Into but overwrites an error already set in target
+func (e *ErrorX) IntoOverwrite(target *error) {
+	if target == nil {
+		return
+	}
+	if err := e.ErrorOrNil(); err != nil {
+		*target = err
+	}
+}
+
 // Unwrap returns the underlying error
 func (e *ErrorX) Unwrap() []error {
 	return e.errs
 }
 
 // Is checks if current error contains given error
+// if given error is a kind marker (ex: ErrNetworkTemporary) or an ErrorX
+// with only a kind (ex: result of OnlyKind) it matches if the kind of
+// current error includes kind of the marker
 func (e *ErrorX) Is(err error) bool {
+	if marker, ok := err.(kindMarker); ok {
+		return IsKind(e, marker.kind)
+	}
+	if marker, ok := err.(*ErrorX); ok && marker.isKindMarker() {
+		return IsKind(e, marker.kind)
+	}
 	x := &ErrorX{}
 	x.init()
 	parseError(x, err)
@@ -166,17 +449,42 @@ func (e *ErrorX) Is(err error) bool {
 	return false
 }
 
+// MatchAny checks if current error matches any of given targets using errors.Is
+// kind markers are supported as in Is and nil targets are ignored
+//
+//	Example:
+//
+//	if x.MatchAny(io.EOF, io.ErrUnexpectedEOF, errkit.ErrDeadline) {
+//		// retry
+//	}
+func (e *ErrorX) MatchAny(targets ...error) bool {
+	for _, target := range targets {
+		if target != nil && errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKindMarker checks if the error only carries a kind
+func (e *ErrorX) isKindMarker() bool {
+	return len(e.errs) == 0 && e.kind != nil && e.kind.String() != ""
+}
+
 // Error returns the error string
 func (e *ErrorX) Error() string {
+	if len(e.errs) == 0 {
+		// only classification is available
+		return "kind=" + strconv.Quote(e.Kind().String())
+	}
 	var sb strings.Builder
 	sb.WriteString("cause=")
 	sb.WriteString(strconv.Quote(e.errs[0].Error()))
 	if e.record != nil && e.record.NumAttrs() > 0 {
 		values := []string{}
-		e.record.Attrs(func(a slog.Attr) bool {
+		for _, a := range e.sortedAttrs() {
 			values = append(values, a.String())
-			return true
-		})
+		}
 		sb.WriteString(Space)
 		sb.WriteString(strings.Join(values, " "))
 	}
@@ -188,6 +496,70 @@ func (e *ErrorX) Error() string {
 		sb.WriteString(Space)
 		sb.WriteString("chain=" + strconv.Quote(strings.Join(chain, ErrChainSeperator)))
 	}
+	if e.dropped > 0 {
+		sb.WriteString(Space)
+		sb.WriteString("(" + strconv.Itoa(e.dropped) + " more errors hidden)")
+	}
+	return sb.String()
+}
+
+// Format implements fmt.Formatter
+//
+//	%s, %v	error string same as Error()
+//	%+v	error string prefixed with kind
+//	%#v	go syntax like representation with kind, errors and attr keys
+//	%q	quoted error string
+func (e *ErrorX) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('#'):
+			errs := []string{}
+			for _, err := range e.errs {
+				errs = append(errs, err.Error())
+			}
+			keys := []string{}
+			for _, attr := range e.sortedAttrs() {
+				keys = append(keys, attr.Key)
+			}
+			_, _ = fmt.Fprintf(s, "&errkit.ErrorX{kind:%q, errs:%#v, attrs:%#v}", e.Kind().String(), errs, keys)
+		case s.Flag('+'):
+			_, _ = fmt.Fprintf(s, "kind=%s %s", e.Kind().String(), e.Error())
+		default:
+			_, _ = io.WriteString(s, e.Error())
+		}
+	case 's':
+		_, _ = io.WriteString(s, e.Error())
+	case 'q':
+		_, _ = io.WriteString(s, strconv.Quote(e.Error()))
+	}
+}
+
+// Tree returns a hierarchical rendering of the error meant for diagnostics
+// the first line contains kind and attributes of the error followed by
+// one branch per error with its kind if it could be classified
+//
+//	kind=network-permanent-error address=127.0.0.1
+//	├─ dial tcp 127.0.0.1:80: connect: connection refused [network-permanent-error]
+//	└─ failed to connect to target
+func (e *ErrorX) Tree() string {
+	var sb strings.Builder
+	sb.WriteString("kind=" + e.Kind().String())
+	for _, a := range e.sortedAttrs() {
+		sb.WriteString(Space)
+		sb.WriteString(a.String())
+	}
+	for i, err := range e.errs {
+		branch, indent := "├─ ", "│  "
+		if i == len(e.errs)-1 {
+			branch, indent = "└─ ", "   "
+		}
+		sb.WriteString("\n" + branch)
+		sb.WriteString(strings.ReplaceAll(strings.TrimSpace(err.Error()), "\n", "\n"+indent))
+		if kind := GetErrorKind(err); !kind.Is(ErrKindUnknown) {
+			sb.WriteString(" [" + kind.String() + "]")
+		}
+	}
 	return sb.String()
 }
 
@@ -199,6 +571,125 @@ func (e *ErrorX) Cause() error {
 	return nil
 }
 
+// Last returns the most recently appended error
+// errors are kept in the order they were appended i.e the root cause
+// is first and messages added by Msgf/Wrap are last, so this usually
+// is the outermost context of the error
+func (e *ErrorX) Last() error {
+	if len(e.errs) > 0 {
+		return e.errs[len(e.errs)-1]
+	}
+	return nil
+}
+
+// OnlyKind returns a new error with same classification but without
+// any error messages, attributes or source, it is safe to be exported
+// ex: as metric labels since messages may contain sensitive data
+func (e *ErrorX) OnlyKind() *ErrorX {
+	return &ErrorX{kind: e.Kind(), severity: e.severity, hasSeverity: e.hasSeverity}
+}
+
+// Redacted returns a copy of the error where matches of given patterns in
+// error messages are replaced with "***", it can be used to export errors
+// whose messages may contain sensitive data (ex: tokens in urls) while the
+// original error is kept untouched, redacted errors lose their type
+//
+//	Example:
+//
+//	token := regexp.MustCompile(`token=[^&\s]+`)
+//	report(x.Redacted([]*regexp.Regexp{token}))
+func (e *ErrorX) Redacted(patterns []*regexp.Regexp) *ErrorX {
+	x := e.clone()
+	for i, err := range x.errs {
+		msg := err.Error()
+		redacted := msg
+		for _, pattern := range patterns {
+			redacted = pattern.ReplaceAllString(redacted, "***")
+		}
+		if redacted != msg {
+			x.errs[i] = errors.New(redacted)
+		}
+	}
+	return x
+}
+
+// FilterKind returns a copy of the error with only the errors classified as
+// given kind on their own (see IsKind) or that contributed given kind to this
+// error (see KindContributions, recorded only if EnableKindTrace is set) ex:
+// to extract network failures from an aggregate, the kind of the copy is set
+// to given kind and nil is returned if no error matches
+//
+//	Example:
+//
+//	if netErrs := x.FilterKind(errkit.ErrKindNetworkTemporary); netErrs != nil {
+//		retry(netErrs)
+//	}
+func (e *ErrorX) FilterKind(kind ErrKind) *ErrorX {
+	if e == nil || kind == nil {
+		return nil
+	}
+	x := e.clone()
+	x.errs = x.errs[:0]
+	for _, err := range e.errs {
+		if e.errHasKind(err, kind) {
+			x.errs = append(x.errs, err)
+		}
+	}
+	if len(x.errs) == 0 {
+		return nil
+	}
+	x.kind = kind
+	x.dropped = 0
+	for k := range x.kindTrace {
+		if !kind.Is(k) {
+			delete(x.kindTrace, k)
+		}
+	}
+	return x
+}
+
+// errHasKind checks if given error of this error is classified as kind
+// on its own or contributed kind to this error, contributions are matched
+// by exact message as a contributor may hold several chained messages
+func (e *ErrorX) errHasKind(err error, kind ErrKind) bool {
+	if IsKind(err, kind) {
+		return true
+	}
+	msg := strings.TrimSpace(err.Error())
+	if msg == "" {
+		return false
+	}
+	for k, msgs := range e.kindTrace {
+		if !kind.Is(k) {
+			continue
+		}
+		for _, contributor := range msgs {
+			if contributor == msg || slices.Contains(strings.Split(contributor, ErrChainSeperator), msg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the error that does not share any state with it
+func (e *ErrorX) clone() *ErrorX {
+	x := &ErrorX{
+		kind:        e.kind,
+		severity:    e.severity,
+		hasSeverity: e.hasSeverity,
+		source:      e.source,
+		errs:        slices.Clone(e.errs),
+		kindTrace:   e.KindContributions(),
+		dropped:     e.dropped,
+	}
+	if e.record != nil {
+		record := e.record.Clone()
+		x.record = &record
+	}
+	return x
+}
+
 // Kind returns the errorkind associated with this error
 // if any
 func (e *ErrorX) Kind() ErrKind {
@@ -220,6 +711,22 @@ func FromError(err error) *ErrorX {
 	return nucleiErr
 }
 
+// FromErrorWithKind is like FromError but also sets the kind of the error
+// it returns nil if err is nil
+//
+//	Example:
+//
+//	return errkit.FromErrorWithKind(err, errkit.ErrKindNetworkPermanent)
+func FromErrorWithKind(err error, kind ErrKind) *ErrorX {
+	if err == nil {
+		return nil
+	}
+	x := &ErrorX{}
+	x.init()
+	parseError(x, err)
+	return x.SetKind(kind)
+}
+
 // New creates a new error with the given message
 // it follows slog pattern of adding and expects in the same way
 //
@@ -243,6 +750,23 @@ func New(msg string, args ...interface{}) *ErrorX {
 	return e
 }
 
+// NewIf is like New but returns nil if cond is false, it keeps validation
+// code free of branching when combined with nil safe methods like Tap
+// Note: check the result against nil before returning it as error since
+// a nil *ErrorX stored in an error interface is not a nil error
+//
+//	Example:
+//
+//	if x := errkit.NewIf(port <= 0, "invalid port", "port", port); x != nil {
+//		return x
+//	}
+func NewIf(cond bool, msg string, args ...interface{}) *ErrorX {
+	if !cond {
+		return nil
+	}
+	return New(msg, args...)
+}
+
 // Msgf adds a message to the error
 // it follows slog pattern of adding and expects in the same way
 //
@@ -262,19 +786,149 @@ func (e *ErrorX) Msgf(format string, args ...interface{}) {
 	}
 	if len(args) == 0 {
 		e.append(errors.New(format))
+		return
 	}
 	e.append(fmt.Errorf(format, args...))
 }
 
+// Appendf is chainable version of Msgf
+// it adds a message to the error and returns the error
+//
+//	Example:
+//
+//	return errkit.New("dial error").Appendf("attempt %d", n).SetKind(errkit.ErrKindNetworkTemporary)
+func (e *ErrorX) Appendf(format string, args ...interface{}) *ErrorX {
+	e.Msgf(format, args...)
+	return e
+}
+
+// Add adds given error to the error, nil errors are skipped
+// it merges kind, attributes and messages of given error the same way
+// as errkit.Join does and returns the error for chaining
+//
+//	Example:
+//
+//	agg := errkit.New("validation failed")
+//	for _, item := range items {
+//		agg.Add(validate(item))
+//	}
+func (e *ErrorX) Add(err error) *ErrorX {
+	if e == nil || err == nil {
+		return e
+	}
+	e.init()
+	parseError(e, err)
+	return e
+}
+
+// AddIf is like Add but only adds the error if cond is true
+//
+//	Example:
+//
+//	agg.AddIf(x > 0, validateX()).AddIf(y > 0, validateY())
+func (e *ErrorX) AddIf(cond bool, err error) *ErrorX {
+	if !cond {
+		return e
+	}
+	return e.Add(err)
+}
+
+// Tap calls fn with the error and returns the error for chaining
+// it allows side effects like logging in the middle of a chain
+// fn is not called if the error is nil
+//
+//	Example:
+//
+//	return errkit.New("dial error").SetKind(errkit.ErrKindNetworkTemporary).Tap(logError).Build()
+func (e *ErrorX) Tap(fn func(*ErrorX)) *ErrorX {
+	if e != nil && fn != nil {
+		fn(e)
+	}
+	return e
+}
+
+// Collapse returns a copy of the error where errors whose messages only
+// differ in numbers, case or whitespace are merged into a single "<msg> (xN)"
+// entry where msg is the message of the first of them and N the number of
+// merged errors, this keeps errors aggregating many similar failures readable
+// ex: timeouts to 500 hosts, errors that are not merged are kept as is
+//
+// Messages of the other merged errors are dropped i.e the ips, ports or other
+// numbers they differ in are lost, the original error is kept untouched
+//
+//	Example:
+//
+//	// cause="dial tcp 10.0.0.1:80: i/o timeout (x3)"
+//	x = x.Collapse()
+func (e *ErrorX) Collapse() *ErrorX {
+	if e == nil {
+		return nil
+	}
+	x := e.clone()
+	if len(e.errs) < 2 {
+		return x
+	}
+	type group struct {
+		err   error
+		count int
+	}
+	var groups []*group
+	byKey := make(map[string]*group, len(e.errs))
+	for _, err := range e.errs {
+		key := collapseKey(err.Error())
+		if g, ok := byKey[key]; ok {
+			g.count++
+			continue
+		}
+		g := &group{err: err, count: 1}
+		byKey[key] = g
+		groups = append(groups, g)
+	}
+	if len(groups) == len(e.errs) {
+		return x
+	}
+	x.errs = make([]error, 0, len(groups))
+	for _, g := range groups {
+		if g.count > 1 {
+			x.errs = append(x.errs, fmt.Errorf("%s (x%d)", g.err.Error(), g.count))
+		} else {
+			x.errs = append(x.errs, g.err)
+		}
+	}
+	return x
+}
+
+// collapseKey normalizes given message so that messages only differing
+// in numbers (ex: ips, ports, durations), case or whitespace are equal
+func collapseKey(msg string) string {
+	var sb strings.Builder
+	digits := false
+	for _, r := range strings.Join(strings.Fields(strings.ToLower(msg)), " ") {
+		if unicode.IsDigit(r) {
+			if !digits {
+				sb.WriteByte('#')
+			}
+			digits = true
+			continue
+		}
+		digits = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
 // SetClass sets the class of the error
 // if underlying error class was already set, then it is given preference
 // when generating final error msg
+// unless set explicitly using SetSeverity, severity of the error defaults
+// to the severity of the kind (see DefaultKindSeverity)
 //
 //	Example:
 //
 //	this is correct (√)
 //	myError.SetKind(errkit.ErrKindNetworkPermanent)
 func (e *ErrorX) SetKind(kind ErrKind) *ErrorX {
+	e.traceKind(kind, e.message())
 	if e.kind == nil {
 		e.kind = kind
 	} else {
@@ -283,6 +937,59 @@ func (e *ErrorX) SetKind(kind ErrKind) *ErrorX {
 	return e
 }
 
+// SetKindString sets the class of the error using given string
+// it is meant for one-off classifications where defining a new
+// ErrKind is not worth it and combines with existing kind like SetKind
+//
+//	Example:
+//
+//	myError.SetKindString("template-error")
+func (e *ErrorX) SetKindString(kind string) *ErrorX {
+	if kind == "" {
+		return e
+	}
+	return e.SetKind(stringErrKind(kind))
+}
+
+// SetKindIfUnset sets the class of the error only if it is not already set
+// unlike SetKind it does not combine given kind with the existing one
+//
+//	Example:
+//
+//	myError.SetKindIfUnset(errkit.ErrKindNetworkTemporary)
+func (e *ErrorX) SetKindIfUnset(kind ErrKind) *ErrorX {
+	if e.kind == nil || e.kind.Is(ErrKindUnknown) {
+		e.kind = kind
+		e.kindTrace = nil
+		e.traceKind(kind, e.message())
+	}
+	return e
+}
+
+// InheritKind sets the class of the error to the class of given error
+// without adopting its messages or attributes, it combines with existing
+// kind like SetKind and is a no-op if the class of given error is unknown
+//
+//	Example:
+//
+//	return errkit.New("sync failed").InheritKind(err)
+func (e *ErrorX) InheritKind(from error) *ErrorX {
+	if from == nil {
+		return e
+	}
+	x := &ErrorX{}
+	parseError(x, from)
+	kind := x.kind
+	if kind == nil {
+		// not classified explicitly, try default kinds
+		kind = GetErrorKind(from)
+	}
+	if kind.Is(ErrKindUnknown) {
+		return e
+	}
+	return e.SetKind(kind)
+}
+
 // ResetKind resets the error class of the error
 //
 //	Example:
@@ -290,6 +997,7 @@ func (e *ErrorX) SetKind(kind ErrKind) *ErrorX {
 //	myError.ResetKind()
 func (e *ErrorX) ResetKind() *ErrorX {
 	e.kind = nil
+	e.kindTrace = nil
 	return e
 }
 
@@ -311,6 +1019,137 @@ func (e *ErrorX) SetAttr(s ...slog.Attr) *ErrorX {
 	return e
 }
 
+// EnrichFromContext adds values of given context keys as attributes
+// keys are converted to attribute keys using fmt.Sprint and missing keys are skipped,
+// if the error is caused by context.DeadlineExceeded the deadline of the context
+// is recorded as well (see SetDeadline)
+//
+//	Example:
+//
+//	myError.EnrichFromContext(ctx, requestIDKey, scanIDKey)
+func (e *ErrorX) EnrichFromContext(ctx context.Context, keys ...any) *ErrorX {
+	if ctx == nil {
+		return e
+	}
+	for _, key := range keys {
+		value := ctx.Value(key)
+		if value == nil {
+			continue
+		}
+		e.init()
+		e.record.Add(slog.Any(fmt.Sprint(key), value))
+	}
+	if deadline, ok := ctx.Deadline(); ok && errors.Is(e, context.DeadlineExceeded) {
+		e.SetDeadline(deadline)
+	}
+	return e
+}
+
+// SetDeadline records the deadline of the operation that failed as the
+// "deadline" attribute, it helps debugging timeouts ex: telling apart
+// a deadline that was too short from one that was already exceeded
+//
+//	Example:
+//
+//	if deadline, ok := ctx.Deadline(); ok {
+//		myError.SetDeadline(deadline)
+//	}
+func (e *ErrorX) SetDeadline(t time.Time) *ErrorX {
+	e.init()
+	e.record.Add(slog.Time(deadlineAttrKey, t))
+	return e
+}
+
+// Deadline returns the deadline recorded with SetDeadline
+// and false if the error does not have a deadline
+func (e *ErrorX) Deadline() (time.Time, bool) {
+	var deadline time.Time
+	var ok bool
+	for _, attr := range e.Attrs() {
+		if attr.Key == deadlineAttrKey && attr.Value.Kind() == slog.KindTime {
+			deadline, ok = attr.Value.Time(), true
+		}
+	}
+	return deadline, ok
+}
+
+// EnrichFromStruct adds values of fields tagged with errattr as attributes
+// the tag holds the attribute key and the omitempty option skips zero values,
+// fields of nested and embedded structs are added as well, prefixed with the key of the
+// struct field and a dot if it is tagged, nil pointers and fields without
+// a tag (or tagged "-") that are not structs are skipped
+//
+//	Example:
+//
+//	type request struct {
+//		Method string `errattr:"method"`
+//		Path   string `errattr:"path"`
+//		ID     string `errattr:"request_id,omitempty"`
+//	}
+//	myError.EnrichFromStruct(req)
+func (e *ErrorX) EnrichFromStruct(v any) *ErrorX {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return e
+	}
+	e.enrichFromStruct(value, "")
+	return e
+}
+
+// enrichFromStruct adds tagged fields of given struct value with keys prefixed by prefix
+func (e *ErrorX) enrichFromStruct(value reflect.Value, prefix string) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		fieldValue := value.Field(i)
+		for fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Pointer {
+			// nil pointer
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("errattr")
+		name, opts, _ := strings.Cut(tag, ",")
+		if !ok || name == "" || name == "-" {
+			if tag != "-" && fieldValue.Kind() == reflect.Struct {
+				e.enrichFromStruct(fieldValue, prefix)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			// exported fields of unexported embedded structs are promoted
+			continue
+		}
+		if fieldValue.Kind() == reflect.Struct && hasErrAttrFields(fieldValue.Type()) {
+			e.enrichFromStruct(fieldValue, prefix+name+".")
+			continue
+		}
+		if opts == "omitempty" && fieldValue.IsZero() {
+			continue
+		}
+		e.init()
+		e.record.Add(slog.Any(prefix+name, fieldValue.Interface()))
+	}
+}
+
+// hasErrAttrFields checks if given struct type has fields tagged with errattr
+func hasErrAttrFields(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		if tag := typ.Field(i).Tag.Get("errattr"); tag != "" && tag != "-" {
+			return true
+		}
+	}
+	return false
+}
+
 // parseError recursively parses all known types of errors
 func parseError(to *ErrorX, err error) {
 	// guard against panics in external libraries calls
@@ -338,17 +1177,36 @@ func parseError(to *ErrorX, err error) {
 		to.init(4)
 	}
 	if len(to.errs) >= MaxErrorDepth {
+		to.drop(err)
 		return
 	}
+	if _, ok := err.(*ErrorX); !ok {
+		// backing array avoids allocations for usual chain depths
+		var path [8]errorKey
+		if isCyclic(err, path[:0]) {
+			parseCyclicError(to, err)
+			return
+		}
+		classifySentinel(to, err)
+	}
 
 	switch v := err.(type) {
 	case *ErrorX:
 		to.append(v.errs...)
+		to.dropped += v.dropped
 		if v.record != nil {
 			if to.record == nil {
 				to.record = v.record
 			} else {
+				if t := v.record.Time; !t.IsZero() && (to.record.Time.IsZero() || t.Before(to.record.Time)) {
+					to.record.Time = t
+				}
+				hasRequestID := to.RequestID() != ""
 				v.record.Attrs(func(a slog.Attr) bool {
+					if a.Key == RequestIDKey && hasRequestID {
+						// first non-empty request id wins
+						return true
+					}
 					to.record.Add(a)
 					return true
 				})
@@ -357,7 +1215,30 @@ func parseError(to *ErrorX, err error) {
 		if to.source == nil && v.source != nil {
 			to.source = v.source
 		}
-		to.kind = CombineErrKinds(to.kind, v.kind)
+		if !to.hasSeverity && v.hasSeverity {
+			to.severity = v.severity
+			to.hasSeverity = true
+		}
+		if v.kind != nil {
+			// combining with nil kind results in an empty kind
+			// which would prevent classification using default kinds
+			to.kind = CombineErrKinds(to.kind, v.kind)
+		}
+		to.mergeKindTrace(v)
+	case MultiError:
+		// checked before WrappedError since such errors may also
+		// implement Unwrap() error returning only a chain of them
+		foundAny := false
+		for _, e := range v.WrappedErrors() {
+			if e == nil {
+				continue
+			}
+			parseError(to, e)
+			foundAny = true
+		}
+		if !foundAny {
+			parseErrorString(to, err.Error())
+		}
 	case JoinedError:
 		foundAny := false
 		for _, e := range v.Unwrap() {
@@ -365,53 +1246,173 @@ func parseError(to *ErrorX, err error) {
 			foundAny = true
 		}
 		if !foundAny {
-			parseError(to, errors.New(err.Error()))
+			parseErrorString(to, err.Error())
 		}
 	case WrappedError:
 		if v.Unwrap() != nil {
 			parseError(to, v.Unwrap())
 		} else {
-			parseError(to, errors.New(err.Error()))
+			parseLeafError(to, err)
 		}
 	case CauseError:
 		to.append(v.Cause())
 		remaining := strings.ReplaceAll(err.Error(), v.Cause().Error(), "")
-		parseError(to, errors.New(remaining))
+		parseErrorString(to, remaining)
 	default:
-		errString := err.Error()
-		// try assigning to enriched error
-		if strings.Contains(errString, DelimArrow) {
-			// Split the error by arrow delim
-			parts := strings.Split(errString, DelimArrow)
-			for i := len(parts) - 1; i >= 0; i-- {
-				part := strings.TrimSpace(parts[i])
-				parseError(to, errors.New(part))
+		parseLeafError(to, err)
+	}
+}
+
+// isCyclic checks if unwrapping given error leads back to an error
+// already on the unwrap path, path holds the keys of errors on the path
+func isCyclic(err error, path []errorKey) bool {
+	if err == nil {
+		return false
+	}
+	key := newErrorKey(err)
+	if slices.Contains(path, key) {
+		return true
+	}
+	path = append(path, key)
+
+	switch v := err.(type) {
+	case MultiError:
+		for _, e := range v.WrappedErrors() {
+			if isCyclic(e, path) {
+				return true
 			}
-		} else if strings.Contains(errString, DelimArrowSerialized) {
-			// Split the error by arrow delim
-			parts := strings.Split(errString, DelimArrowSerialized)
-			for i := len(parts) - 1; i >= 0; i-- {
-				part := strings.TrimSpace(parts[i])
-				parseError(to, errors.New(part))
+		}
+	case JoinedError:
+		for _, e := range v.Unwrap() {
+			if isCyclic(e, path) {
+				return true
 			}
-		} else if strings.Contains(errString, DelimSemiColon) {
-			// Split the error by semi-colon delim
-			parts := strings.Split(errString, DelimSemiColon)
-			for _, part := range parts {
-				part = strings.TrimSpace(part)
-				parseError(to, errors.New(part))
+		}
+	case WrappedError:
+		return isCyclic(v.Unwrap(), path)
+	}
+	return false
+}
+
+// errorKey is the identity of an error, pointers are identified by address
+// and other errors by type and message since they may not be comparable
+type errorKey struct {
+	typ reflect.Type
+	ptr uintptr
+	msg string
+}
+
+func newErrorKey(err error) errorKey {
+	v := reflect.ValueOf(err)
+	if v.Kind() == reflect.Pointer {
+		return errorKey{typ: v.Type(), ptr: v.Pointer()}
+	}
+	return errorKey{typ: v.Type(), msg: err.Error()}
+}
+
+// parseCyclicError parses error whose unwrap path contains a cycle
+// walking such chain (including errors.Is) would never terminate so
+// cyclic errors are reduced to their message, acyclic errors joined
+// with them are parsed as usual
+func parseCyclicError(to *ErrorX, err error) {
+	var errs []error
+	switch v := err.(type) {
+	case MultiError:
+		errs = v.WrappedErrors()
+	case JoinedError:
+		errs = v.Unwrap()
+	default:
+		parseErrorString(to, err.Error())
+		return
+	}
+	for _, e := range errs {
+		parseError(to, e)
+	}
+}
+
+// parseLeafError appends given error as is if it can not be split
+// further, this preserves its type for errors.As and errors.Is
+func parseLeafError(to *ErrorX, err error) {
+	errString := err.Error()
+	if DisableDelimiterSplitting || !hasDelimiter(errString) {
+		// this cannot be further unwrapped
+		to.append(err)
+		return
+	}
+	if to.errs == nil {
+		// avoid growing the slice while appending parts
+		to.errs = make([]error, 0, MaxErrorDepth)
+	}
+	parseErrorString(to, errString)
+}
+
+// hasDelimiter checks if given error string contains any known delimiter
+func hasDelimiter(errString string) bool {
+	return strings.Contains(errString, DelimArrow) ||
+		strings.Contains(errString, DelimArrowSerialized) ||
+		strings.Contains(errString, DelimSemiColon) ||
+		strings.Contains(errString, MultiLineErrPrefix)
+}
+
+// parseErrorString splits given error string on known delimiters
+// and appends all parts, it works on substrings and only allocates
+// errors for parts that are actually appended
+func parseErrorString(to *ErrorX, errString string) {
+	if len(to.errs) >= MaxErrorDepth {
+		if !to.contains(errString) {
+			to.dropped++
+		}
+		return
+	}
+	if DisableDelimiterSplitting {
+		to.appendString(errString)
+		return
+	}
+
+	switch {
+	case strings.Contains(errString, DelimArrow):
+		// parts are in reverse order i.e last part is the cause
+		for {
+			idx := strings.LastIndex(errString, DelimArrow)
+			if idx < 0 {
+				parseErrorString(to, strings.TrimSpace(errString))
+				return
 			}
-		} else if strings.Contains(errString, MultiLineErrPrefix) {
-			// remove prefix
-			msg := strings.ReplaceAll(errString, MultiLineErrPrefix, "")
-			parts := strings.Split(msg, DelimMultiLine)
-			for _, part := range parts {
-				part = strings.TrimSpace(part)
-				parseError(to, errors.New(part))
+			parseErrorString(to, strings.TrimSpace(errString[idx+len(DelimArrow):]))
+			errString = errString[:idx]
+		}
+	case strings.Contains(errString, DelimArrowSerialized):
+		for {
+			idx := strings.LastIndex(errString, DelimArrowSerialized)
+			if idx < 0 {
+				parseErrorString(to, strings.TrimSpace(errString))
+				return
 			}
-		} else {
-			// this cannot be further unwrapped
-			to.append(err)
+			parseErrorString(to, strings.TrimSpace(errString[idx+len(DelimArrowSerialized):]))
+			errString = errString[:idx]
 		}
+	case strings.Contains(errString, DelimSemiColon):
+		for {
+			part, remaining, found := strings.Cut(errString, DelimSemiColon)
+			parseErrorString(to, strings.TrimSpace(part))
+			if !found {
+				return
+			}
+			errString = remaining
+		}
+	case strings.Contains(errString, MultiLineErrPrefix):
+		// remove prefix
+		errString = strings.ReplaceAll(errString, MultiLineErrPrefix, "")
+		for {
+			part, remaining, found := strings.Cut(errString, DelimMultiLine)
+			parseErrorString(to, strings.TrimSpace(part))
+			if !found {
+				return
+			}
+			errString = remaining
+		}
+	default:
+		// this cannot be further unwrapped
+		to.appendString(errString)
 	}
 }
@@ -2,7 +2,10 @@ package errkit
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"runtime/debug"
+	"slices"
 )
 
 // Proxy to StdLib errors.Is
@@ -141,6 +144,45 @@ func Cause(err error) error {
 	return x.Cause()
 }
 
+// Chain returns the unwrap chain of given error ordered from the outermost
+// error to the root cause, single unwrap chains are followed with Unwrap()
+// and joined errors are flattened depth first in order, so unlike Errors
+// intermediate wrapping errors are kept as is ex: fmt.Errorf("read: %w", err)
+// returns [read: <err>, <err>], errors repeating in their own chain are
+// returned once without walking them again
+func Chain(err error) []error {
+	var chain []error
+	walkChain(err, nil, &chain)
+	return chain
+}
+
+// walkChain appends given error and the errors it wraps to chain
+// path holds the errors being walked to detect cycles
+func walkChain(err error, path []errorKey, chain *[]error) {
+	for err != nil {
+		*chain = append(*chain, err)
+		key := newErrorKey(err)
+		if slices.Contains(path, key) {
+			return
+		}
+		path = append(path, key)
+
+		switch v := err.(type) {
+		case MultiError:
+			for _, e := range v.WrappedErrors() {
+				walkChain(e, path, chain)
+			}
+			return
+		case JoinedError:
+			for _, e := range v.Unwrap() {
+				walkChain(e, path, chain)
+			}
+			return
+		}
+		err = errors.Unwrap(err)
+	}
+}
+
 // WithMessage
 func WithMessage(err error, message string) error {
 	if err == nil {
@@ -193,6 +235,62 @@ func IsNetworkPermanentErr(err error) bool {
 	return isNetworkPermanentErr(x)
 }
 
+// IsInternalErr checks if given error is caused by a bug in the code itself
+// i.e it is of kind ErrKindInternal or ErrKindPanic, such errors are not
+// resolved by retrying unlike temporary network errors
+func IsInternalErr(err error) bool {
+	return IsKind(err, ErrKindInternal, ErrKindPanic)
+}
+
+// IsTimeout checks if given error is a timeout error
+// i.e it or any of its underlying errors implement Timeout() bool
+// like net.Error and return true or it is of kind ErrKindDeadline
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	x := &ErrorX{}
+	parseError(x, err)
+	if x.kind != nil && x.kind.Is(ErrKindDeadline) {
+		return true
+	}
+	return anyErr(err, x.errs, func(e error) bool {
+		var t interface{ Timeout() bool }
+		return errors.As(e, &t) && t.Timeout()
+	})
+}
+
+// IsTemporary checks if given error is a temporary error
+// i.e it or any of its underlying errors implement Temporary() bool
+// and return true or it is of kind ErrKindNetworkTemporary
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	x := &ErrorX{}
+	parseError(x, err)
+	if x.kind != nil && x.kind.Is(ErrKindNetworkTemporary) {
+		return true
+	}
+	return anyErr(err, x.errs, func(e error) bool {
+		var t interface{ Temporary() bool }
+		return errors.As(e, &t) && t.Temporary()
+	})
+}
+
+// anyErr checks if given error or any of the parsed errors match
+func anyErr(err error, errs []error, match func(error) bool) bool {
+	if match(err) {
+		return true
+	}
+	for _, e := range errs {
+		if match(e) {
+			return true
+		}
+	}
+	return false
+}
+
 // With adds extra attributes to the error
 //
 //	err = errkit.With(err,"resource",domain)
@@ -248,8 +346,11 @@ func ToSlogAttrGroup(err error) slog.Attr {
 //		"cause": "<cause>",
 //		"errors": [
 //			<errs>...
-//		]
+//		],
+//		"source": "<file>:<line>"
 //	}
+//
+// source is only present if the error was created with EnableTrace
 func ToSlogAttrs(err error) []slog.Attr {
 	x := &ErrorX{}
 	parseError(x, err)
@@ -263,6 +364,9 @@ func ToSlogAttrs(err error) []slog.Attr {
 	if len(x.errs) > 0 {
 		attrs = append(attrs, slog.Any("errors", x.errs))
 	}
+	if x.source != nil {
+		attrs = append(attrs, slog.String("source", fmt.Sprintf("%s:%d", x.source.File, x.source.Line)))
+	}
 	return attrs
 }
 
@@ -280,3 +384,20 @@ func GetAttrValue(err error, key string) slog.Value {
 	}
 	return slog.Value{}
 }
+
+// Must returns the value if err is nil otherwise it panics
+// with an *ErrorX of kind ErrKindPanic containing the stack trace
+// it is meant to be used in initialization code
+//
+//	cfg := errkit.Must(loadConfig())
+func Must[T any](v T, err error) T {
+	if err == nil {
+		return v
+	}
+	x := &ErrorX{}
+	x.init()
+	parseError(x, err)
+	x.SetKind(ErrKindPanic)
+	x.record.Add(slog.String("stack", string(debug.Stack())))
+	panic(x)
+}
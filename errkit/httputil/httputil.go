@@ -0,0 +1,102 @@
+// httputil classifies errors returned by http clients into errkit error kinds
+package httputil
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/projectdiscovery/utils/errkit"
+)
+
+var (
+	// ErrKindHTTPClient indicates a 4xx response from the server
+	// these are caused by the request itself and retrying will not help
+	ErrKindHTTPClient = errkit.NewPrimitiveErrKind("http-client-error", "http client error", nil)
+	// ErrKindHTTPServer indicates a 5xx response from the server
+	// these may be resolved by retrying the request later
+	ErrKindHTTPServer = errkit.NewPrimitiveErrKind("http-server-error", "http server error", nil)
+)
+
+func init() {
+	errkit.DefaultKindSeverity[ErrKindHTTPClient.String()] = slog.LevelWarn
+	errkit.DefaultKindSeverity[ErrKindHTTPServer.String()] = slog.LevelError
+}
+
+// Classify returns an ErrorX for given response and error
+// transport errors are classified into network kinds, 404 responses into
+// errkit.ErrKindNotFound, other 4xx responses into ErrKindHTTPClient
+// and 5xx responses into ErrKindHTTPServer
+// it returns nil if there is no error and the response is not an error response
+//
+//	resp, err := client.Do(req)
+//	if x := httputil.Classify(resp, err); x != nil {
+//		return x
+//	}
+func Classify(resp *http.Response, err error) *errkit.ErrorX {
+	var args []any
+	if resp != nil {
+		args = append(args, "status_code", resp.StatusCode)
+		if resp.Request != nil && resp.Request.URL != nil {
+			args = append(args, "url", resp.Request.URL.String())
+		}
+	}
+
+	if err != nil {
+		x := errkit.FromError(errkit.With(err, args...))
+		return x.SetKind(errkit.GetErrorKind(err))
+	}
+
+	if resp == nil {
+		return nil
+	}
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return errkit.New(resp.Status, args...).SetKind(ErrKindHTTPServer)
+	case resp.StatusCode == http.StatusNotFound:
+		return errkit.New(resp.Status, args...).SetKind(errkit.ErrKindNotFound)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return errkit.New(resp.Status, args...).SetKind(ErrKindHTTPClient)
+	}
+	return nil
+}
+
+// StatusCode returns the http status code to respond with for given error
+// it returns 0 for nil error and http.StatusInternalServerError for internal
+// errors (see errkit.IsInternalErr) irrespective of other kinds they carry
+// and for errors that do not have a kind with a known mapping, temporary
+// network errors map to http.StatusServiceUnavailable while permanent
+// network and http server errors map to http.StatusBadGateway
+//
+//	if code := httputil.StatusCode(err); code != 0 {
+//		http.Error(w, err.Error(), code)
+//	}
+func StatusCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch {
+	case errkit.IsInternalErr(err):
+		return http.StatusInternalServerError
+	case errkit.IsKind(err, errkit.ErrKindNotFound):
+		return http.StatusNotFound
+	case errkit.IsKind(err, ErrKindHTTPClient):
+		return http.StatusBadRequest
+	case errkit.IsKind(err, errkit.ErrKindDeadline):
+		return http.StatusGatewayTimeout
+	case errkit.IsKind(err, errkit.ErrKindNetworkTemporary):
+		return http.StatusServiceUnavailable
+	case errkit.IsKind(err, ErrKindHTTPServer, errkit.ErrKindNetworkPermanent):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// Retryable checks if the request that caused given error may succeed
+// when retried i.e the error is a temporary network, deadline or http
+// server error and not an internal error (see errkit.IsInternalErr)
+func Retryable(err error) bool {
+	if err == nil || errkit.IsInternalErr(err) {
+		return false
+	}
+	return errkit.IsKind(err, errkit.ErrKindNetworkTemporary, errkit.ErrKindDeadline, ErrKindHTTPServer)
+}
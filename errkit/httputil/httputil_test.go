@@ -0,0 +1,118 @@
+package httputil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/projectdiscovery/utils/errkit"
+	_ "github.com/projectdiscovery/utils/errkit/sqlutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	u, _ := url.Parse("https://example.com/api")
+	req := &http.Request{URL: u}
+
+	t.Run("Server Error", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable", Request: req}
+		x := Classify(resp, nil)
+		require.NotNil(t, x)
+		require.True(t, errkit.IsKind(x, ErrKindHTTPServer))
+		require.Equal(t, int64(503), errkit.GetAttrValue(x, "status_code").Int64())
+		require.Equal(t, "https://example.com/api", errkit.GetAttrValue(x, "url").String())
+	})
+
+	t.Run("Client Error", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusForbidden, Status: "403 Forbidden", Request: req}
+		x := Classify(resp, nil)
+		require.NotNil(t, x)
+		require.True(t, errkit.IsKind(x, ErrKindHTTPClient))
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Request: req}
+		x := Classify(resp, nil)
+		require.NotNil(t, x)
+		require.True(t, errkit.IsKind(x, errkit.ErrKindNotFound))
+		require.False(t, errkit.IsKind(x, ErrKindHTTPClient))
+	})
+
+	t.Run("Dial Error", func(t *testing.T) {
+		err := errors.New("dial tcp 127.0.0.1:8000: connect: connection refused")
+		x := Classify(nil, err)
+		require.NotNil(t, x)
+		require.True(t, errkit.IsKind(x, errkit.ErrKindNetworkPermanent))
+	})
+
+	t.Run("No Error", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Request: req}
+		require.Nil(t, Classify(resp, nil))
+		require.Nil(t, Classify(nil, nil))
+	})
+}
+
+func TestSeverity(t *testing.T) {
+	require.Equal(t, slog.LevelWarn, errkit.KindSeverity(ErrKindHTTPClient))
+	require.Equal(t, slog.LevelError, errkit.KindSeverity(ErrKindHTTPServer))
+}
+
+func TestStatusCode(t *testing.T) {
+	_, statErr := os.Stat("/does/not/exist")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"Nil", nil, 0},
+		{"Not Exist", statErr, http.StatusNotFound},
+		{"No Rows", fmt.Errorf("get user: %w", sql.ErrNoRows), http.StatusNotFound},
+		{"Not Found Kind", errkit.New("user not found").SetKind(errkit.ErrKindNotFound), http.StatusNotFound},
+		{"Client", errkit.New("bad input").SetKind(ErrKindHTTPClient), http.StatusBadRequest},
+		{"Deadline", context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{"Network Temporary", errkit.New("connection reset").SetKind(errkit.ErrKindNetworkTemporary), http.StatusServiceUnavailable},
+		{"Network Permanent", errors.New("dial tcp: lookup example.invalid: no such host"), http.StatusBadGateway},
+		{"Server", errkit.New("503 Service Unavailable").SetKind(ErrKindHTTPServer), http.StatusBadGateway},
+		{"Unknown", errors.New("something went wrong"), http.StatusInternalServerError},
+		{"EOF", io.EOF, http.StatusServiceUnavailable},
+		{"Internal", errkit.New("nil config").SetKind(errkit.ErrKindInternal), http.StatusInternalServerError},
+		{"Panic", errkit.New("index out of range").SetKind(errkit.ErrKindPanic), http.StatusInternalServerError},
+		{"Internal Not Found", errkit.New("missing handler").SetKind(errkit.ErrKindNotFound).SetKind(errkit.ErrKindInternal), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, StatusCode(tt.err))
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Network Temporary", errkit.New("connection reset").SetKind(errkit.ErrKindNetworkTemporary), true},
+		{"Deadline", context.DeadlineExceeded, true},
+		{"Server", errkit.New("503 Service Unavailable").SetKind(ErrKindHTTPServer), true},
+		{"Network Permanent", errors.New("dial tcp: lookup example.invalid: no such host"), false},
+		{"Client", errkit.New("bad input").SetKind(ErrKindHTTPClient), false},
+		{"Not Found", errkit.New("user not found").SetKind(errkit.ErrKindNotFound), false},
+		{"Unknown", errors.New("something went wrong"), false},
+		{"Internal", errkit.New("nil config").SetKind(errkit.ErrKindInternal), false},
+		{"Internal Temporary", errkit.New("retry loop").SetKind(errkit.ErrKindNetworkTemporary).SetKind(errkit.ErrKindInternal), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Retryable(tt.err))
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package errkit
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
 
 var (
 	_ json.Marshaler  = &ErrorX{}
+	_ fmt.Formatter   = &ErrorX{}
+	_ slog.LogValuer  = &ErrorX{}
 	_ JoinedError     = &ErrorX{}
 	_ CauseError      = &ErrorX{}
 	_ ComparableError = &ErrorX{}
@@ -19,6 +25,13 @@ type JoinedError interface {
 	Unwrap() []error
 }
 
+// MultiError is implemented by errors aggregating multiple errors
+// ex: *multierror.Error of github.com/hashicorp/go-multierror
+type MultiError interface {
+	// WrappedErrors returns the aggregated errors
+	WrappedErrors() []error
+}
+
 // CauseError is implemented by errors that have a cause
 type CauseError interface {
 	// Cause return the original error that caused this without any wrapping
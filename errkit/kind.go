@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"golang.org/x/exp/maps"
 )
@@ -23,11 +26,46 @@ var (
 	// and in most cases are server side issues (ex: server connects but does not respond at all)
 	// a manual intervention is required
 	ErrKindDeadline = NewPrimitiveErrKind("deadline-error", "deadline error", isDeadlineErr)
+	// ErrKindNotFound indicates that a looked up resource does not exist
+	// these are client side issues and are not resolved by retrying
+	// ex: file does not exist, no rows in result set (see errkit/sqlutil), http 404
+	ErrKindNotFound = NewPrimitiveErrKind("not-found-error", "resource not found", nil)
+	// ErrKindPanic indicates an error that was raised as a panic
+	// ex: errors panicked by Must in initialization code
+	ErrKindPanic = NewPrimitiveErrKind("panic-error", "panic error", nil)
+	// ErrKindInternal indicates a programmer error i.e a bug in the code itself
+	// unlike network errors these are not resolved by retrying and need a fix
+	// ex: nil dereference, violated invariant, unreachable branch
+	ErrKindInternal = NewPrimitiveErrKind("internal-error", "internal error", nil)
 	// ErrKindUnknown indicates an unknown error class
 	// that has not been implemented yet this is used as fallback when converting a slog Item
 	ErrKindUnknown = NewPrimitiveErrKind("unknown-error", "unknown error", nil)
 )
 
+// kind markers of built-in kinds to match errors by kind using errors.Is
+//
+//	if errors.Is(err, errkit.ErrNetworkTemporary) {
+//		// retry
+//	}
+var (
+	ErrNetworkTemporary error = kindMarker{kind: ErrKindNetworkTemporary}
+	ErrNetworkPermanent error = kindMarker{kind: ErrKindNetworkPermanent}
+	ErrDeadline         error = kindMarker{kind: ErrKindDeadline}
+	ErrNotFound         error = kindMarker{kind: ErrKindNotFound}
+	ErrPanic            error = kindMarker{kind: ErrKindPanic}
+	ErrInternal         error = kindMarker{kind: ErrKindInternal}
+)
+
+// kindMarker is an immutable error that only carries a kind
+// an ErrorX matches it with errors.Is if its kind includes the kind
+type kindMarker struct {
+	kind ErrKind
+}
+
+func (k kindMarker) Error() string {
+	return "kind=" + strconv.Quote(k.kind.String())
+}
+
 var (
 	// DefaultErrorKinds is the default error kinds used in classification
 	// if one intends to add more default error kinds it must be done in init() function
@@ -62,6 +100,8 @@ type primitiveErrKind struct {
 	id         string
 	info       string
 	represents func(*ErrorX) bool
+	// bit of the kind in kindBits, zero for non builtin kinds
+	bit kindBits
 }
 
 func (e *primitiveErrKind) Is(kind ErrKind) bool {
@@ -93,6 +133,19 @@ func NewPrimitiveErrKind(id string, info string, represents func(*ErrorX) bool)
 	return p
 }
 
+// stringKinds caches kinds created from strings so that
+// same string always maps to same kind while combining
+var stringKinds sync.Map
+
+// stringErrKind returns a lightweight error kind for given string
+func stringErrKind(s string) ErrKind {
+	if kind, ok := stringKinds.Load(s); ok {
+		return kind.(ErrKind)
+	}
+	kind, _ := stringKinds.LoadOrStore(s, NewPrimitiveErrKind(s, s, nil))
+	return kind.(ErrKind)
+}
+
 func isNetworkTemporaryErr(err *ErrorX) bool {
 	if err.Cause() != nil {
 		return os.IsTimeout(err.Cause())
@@ -157,9 +210,16 @@ func isDeadlineErr(err *ErrorX) bool {
 
 type multiKind struct {
 	kinds []ErrKind
+	// bits of kinds if all of them are builtin kinds
+	bits kindBits
 }
 
 func (e *multiKind) Is(kind ErrKind) bool {
+	if e.bits != 0 {
+		if p, ok := kind.(*primitiveErrKind); ok && p.bit != 0 {
+			return e.bits&p.bit != 0
+		}
+	}
 	for _, k := range e.kinds {
 		if k.Is(kind) {
 			return true
@@ -187,11 +247,11 @@ func (e *multiKind) Represents(err *ErrorX) bool {
 }
 
 func (e *multiKind) String() string {
-	var str string
+	ids := make([]string, 0, len(e.kinds))
 	for _, k := range e.kinds {
-		str += k.String() + ","
+		ids = append(ids, k.String())
 	}
-	return strings.TrimSuffix(str, ",")
+	return strings.Join(ids, KindSeparator)
 }
 
 func (e *multiKind) Description() string {
@@ -208,6 +268,14 @@ func (e *multiKind) Description() string {
 // It is recommended to implement a hierarchical error kind
 // instead of using this outside of errkit
 func CombineErrKinds(kind ...ErrKind) ErrKind {
+	if bits, ok := builtinBits(kind); ok {
+		return combineBits(bits)
+	}
+	return combineErrKinds(kind)
+}
+
+// combineErrKinds is the implementation of CombineErrKinds for any kinds
+func combineErrKinds(kind []ErrKind) ErrKind {
 	// while combining it also consolidates child error kinds into parent
 	// but note it currently does not support deeply nested childs
 	// and can only consolidate immediate childs
@@ -252,6 +320,39 @@ func CombineErrKinds(kind ...ErrKind) ErrKind {
 	return f
 }
 
+// ParseKind returns the error kind represented by given string i.e the
+// inverse of ErrKind.String, ids of combined kinds are split on KindSeparator
+// and ids of builtin kinds or kinds in DefaultErrorKinds resolve to them,
+// other ids resolve to kinds like those of SetKindString
+//
+//	Example:
+//
+//	kind := errkit.ParseKind("network-temporary-error,deadline-error")
+func ParseKind(s string) ErrKind {
+	var kinds []ErrKind
+	for _, id := range strings.Split(s, KindSeparator) {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		kinds = append(kinds, kindByID(id))
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	return CombineErrKinds(kinds...)
+}
+
+// kindByID returns the builtin or default error kind with given id
+func kindByID(id string) ErrKind {
+	for _, kind := range append(slices.Clone(builtinKinds), DefaultErrorKinds...) {
+		if kind.String() == id {
+			return kind
+		}
+	}
+	return stringErrKind(id)
+}
+
 // GetErrorKind returns the first error kind from the error
 // extra error kinds can be passed as optional arguments
 func GetErrorKind(err error, defs ...ErrKind) ErrKind {
@@ -0,0 +1,86 @@
+package errkit
+
+import "math/bits"
+
+// kindBits is a set of builtin error kinds where bit i represents builtinKinds[i]
+// combining builtin kinds is then an OR and checking membership a bit test
+type kindBits uint16
+
+// builtinKinds are the error kinds declared by errkit
+var builtinKinds = []ErrKind{
+	ErrKindNetworkTemporary,
+	ErrKindNetworkPermanent,
+	ErrKindDeadline,
+	ErrKindNotFound,
+	ErrKindPanic,
+	ErrKindInternal,
+	ErrKindUnknown,
+}
+
+// combinedKinds holds the combined kind of every set of two or more builtin
+// kinds indexed by its bits, these are shared since kinds are immutable
+var combinedKinds []*multiKind
+
+func init() {
+	for i, kind := range builtinKinds {
+		kind.(*primitiveErrKind).bit = 1 << i
+	}
+	combinedKinds = make([]*multiKind, 1<<len(builtinKinds))
+	for set := range combinedKinds {
+		if bits.OnesCount(uint(set)) < 2 {
+			continue
+		}
+		combined := &multiKind{bits: kindBits(set)}
+		for i, kind := range builtinKinds {
+			if set&(1<<i) != 0 {
+				combined.kinds = append(combined.kinds, kind)
+			}
+		}
+		combinedKinds[set] = combined
+	}
+}
+
+// builtinBits returns the bits of given kinds and false
+// if any of them is not a builtin kind (or combination of them)
+func builtinBits(kinds []ErrKind) (kindBits, bool) {
+	var set kindBits
+	for _, kind := range kinds {
+		switch v := kind.(type) {
+		case nil:
+		case *primitiveErrKind:
+			if v.bit == 0 {
+				return 0, false
+			}
+			set |= v.bit
+		case *multiKind:
+			if v.bits == 0 && len(v.kinds) > 0 {
+				return 0, false
+			}
+			set |= v.bits
+		default:
+			return 0, false
+		}
+	}
+	return set, true
+}
+
+// combineBits returns the combined kind of given builtin kinds
+// following the same rules as CombineErrKinds
+func combineBits(set kindBits) ErrKind {
+	unknown := ErrKindUnknown.(*primitiveErrKind).bit
+	if bits.OnesCount16(uint16(set)) > 1 {
+		set &^= unknown
+	}
+	for bits.OnesCount16(uint16(set)) > max(MaxErrorDepth, 0) {
+		// drop the highest kind
+		set &^= 1 << (bits.Len16(uint16(set)) - 1)
+	}
+	switch bits.OnesCount16(uint16(set)) {
+	case 0:
+		return &multiKind{}
+	case 1:
+		return builtinKinds[bits.TrailingZeros16(uint16(set))]
+	default:
+		return combinedKinds[set]
+	}
+}
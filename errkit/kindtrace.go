@@ -0,0 +1,77 @@
+package errkit
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// KindContributions returns the kinds that contributed to the kind of this
+// error mapped to the error messages that introduced them
+// it is only populated when EnableKindTrace is set and is meant for
+// diagnosing misclassification of combined errors
+//
+//	Example:
+//
+//	errkit.EnableKindTrace = true
+//	err := errkit.Join(netErr, deadlineErr)
+//	for kind, msgs := range errkit.FromError(err).KindContributions() {
+//		fmt.Println(kind, msgs)
+//	}
+func (e *ErrorX) KindContributions() map[ErrKind][]string {
+	if len(e.kindTrace) == 0 {
+		return nil
+	}
+	contributions := make(map[ErrKind][]string, len(e.kindTrace))
+	for kind, msgs := range e.kindTrace {
+		contributions[kind] = slices.Clone(msgs)
+	}
+	return contributions
+}
+
+// traceKind records given message as contributor of given kind
+// combined kinds are recorded per contained kind
+func (e *ErrorX) traceKind(kind ErrKind, msgs ...string) {
+	if !EnableKindTrace || kind == nil || kind.String() == "" {
+		return
+	}
+	if val, ok := kind.(*multiKind); ok {
+		for _, k := range val.kinds {
+			e.traceKind(k, msgs...)
+		}
+		return
+	}
+	if e.kindTrace == nil {
+		e.kindTrace = make(map[ErrKind][]string)
+	}
+	for _, msg := range msgs {
+		if !slices.Contains(e.kindTrace[kind], msg) {
+			e.kindTrace[kind] = append(e.kindTrace[kind], msg)
+		}
+	}
+}
+
+// mergeKindTrace records contributions of given error, errors without
+// a trace contribute their kind with their own message
+func (e *ErrorX) mergeKindTrace(from *ErrorX) {
+	if !EnableKindTrace {
+		return
+	}
+	if len(from.kindTrace) == 0 {
+		e.traceKind(from.kind, from.message())
+		return
+	}
+	for _, kind := range maps.Keys(from.kindTrace) {
+		e.traceKind(kind, from.kindTrace[kind]...)
+	}
+}
+
+// message returns the error messages without attributes
+func (e *ErrorX) message() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, strings.TrimSpace(err.Error()))
+	}
+	return strings.Join(msgs, ErrChainSeperator)
+}
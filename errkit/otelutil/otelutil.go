@@ -0,0 +1,83 @@
+// otelutil records errkit errors on OpenTelemetry spans
+// it lives in its own package to keep the OpenTelemetry dependency
+// out of packages that only import errkit
+package otelutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/projectdiscovery/utils/errkit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AttributePrefix is prepended to keys of span attributes set by RecordError
+const AttributePrefix = "error."
+
+// RecordError records given error on the span and sets the span status to error
+// kind, severity and attributes of the error are set as span attributes prefixed
+// with AttributePrefix (ex: error.kind, error.severity, error.address) and the
+// error itself is recorded as an exception event, nil errors are ignored
+//
+//	ctx, span := tracer.Start(ctx, "dial")
+//	defer span.End()
+//	if err := dial(ctx); err != nil {
+//		otelutil.RecordError(span, err)
+//		return err
+//	}
+func RecordError(span trace.Span, err error) {
+	if span == nil || err == nil || !span.IsRecording() {
+		return
+	}
+	x := errkit.FromError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(Attributes(x)...)
+	span.RecordError(err)
+}
+
+// Attributes returns span attributes of given error sorted by key
+// messages of the error are not included since they are recorded
+// with the exception event
+func Attributes(x *errkit.ErrorX) []attribute.KeyValue {
+	if x == nil {
+		return nil
+	}
+	m := x.ToMap()
+	delete(m, "errors")
+
+	attrs := make([]attribute.KeyValue, 0, len(m))
+	for key, value := range m {
+		attrs = append(attrs, toAttribute(AttributePrefix+key, value))
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Key < attrs[j].Key
+	})
+	return attrs
+}
+
+// toAttribute converts given value to span attribute
+// values of unsupported types are formatted as strings
+func toAttribute(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case uint64:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
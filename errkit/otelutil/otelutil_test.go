@@ -0,0 +1,74 @@
+package otelutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/utils/errkit"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// mockSpan records status, attributes and errors set on it
+type mockSpan struct {
+	noop.Span
+	status      codes.Code
+	description string
+	attrs       map[attribute.Key]attribute.Value
+	errs        []error
+}
+
+func (s *mockSpan) IsRecording() bool { return true }
+
+func (s *mockSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+	s.description = description
+}
+
+func (s *mockSpan) SetAttributes(kv ...attribute.KeyValue) {
+	if s.attrs == nil {
+		s.attrs = make(map[attribute.Key]attribute.Value)
+	}
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *mockSpan) RecordError(err error, _ ...trace.EventOption) {
+	s.errs = append(s.errs, err)
+}
+
+func TestRecordError(t *testing.T) {
+	err := errkit.New("failed to dial", "address", "127.0.0.1", "port", 443, "elapsed", time.Second).
+		SetKind(errkit.ErrKindNetworkTemporary)
+
+	span := &mockSpan{}
+	RecordError(span, err)
+
+	require.Equal(t, codes.Error, span.status)
+	require.Equal(t, err.Error(), span.description)
+	require.Equal(t, []error{err}, span.errs)
+	require.Equal(t, map[attribute.Key]attribute.Value{
+		"error.kind":     attribute.StringValue(errkit.ErrKindNetworkTemporary.String()),
+		"error.severity": attribute.StringValue("ERROR"),
+		"error.address":  attribute.StringValue("127.0.0.1"),
+		"error.port":     attribute.Int64Value(443),
+		"error.elapsed":  attribute.StringValue("1s"),
+	}, span.attrs)
+
+	// nil errors are ignored
+	span = &mockSpan{}
+	RecordError(span, nil)
+	require.Equal(t, codes.Unset, span.status)
+	require.Nil(t, span.attrs)
+
+	// plain errors are classified
+	span = &mockSpan{}
+	RecordError(span, errors.New("i/o timeout"))
+	require.Equal(t, codes.Error, span.status)
+	require.Contains(t, span.attrs, attribute.Key("error.kind"))
+}
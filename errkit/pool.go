@@ -0,0 +1,66 @@
+package errkit
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var errorXPool = sync.Pool{
+	New: func() any {
+		return &ErrorX{}
+	},
+}
+
+// GetX returns an empty ErrorX from a pool, it is meant for
+// extreme throughput paths where short lived errors are created
+// and discarded frequently, for all other cases use New
+//
+// Lifetime rules:
+//   - the returned error must be released using Release once it is no longer used
+//   - it must not be used or retained after Release
+//   - it must not be released if it was returned to a caller or merged
+//     into another error (ex: Wrap, Append, FromError) since they share state
+//
+// Example:
+//
+//	x := errkit.GetX()
+//	defer x.Release()
+//	x.Msgf("i/o timeout")
+func GetX() *ErrorX {
+	x := errorXPool.Get().(*ErrorX)
+	if x.record == nil {
+		// skip [runtime.Callers, ErrorX.init, GetX]
+		x.init(3)
+		return x
+	}
+	// reuse record of previous use
+	if EnableTimestamp {
+		x.record.Time = time.Now()
+	}
+	if EnableTrace {
+		// skip [runtime.Callers, callerSource, GetX]
+		x.source = callerSource(3)
+	}
+	return x
+}
+
+// Release resets the error and puts it back to the pool
+// the error must not be used after calling Release
+func (e *ErrorX) Release() {
+	if e == nil {
+		return
+	}
+	clear(e.errs)
+	e.errs = e.errs[:0]
+	e.kind = nil
+	e.kindTrace = nil
+	e.dropped = 0
+	e.severity = 0
+	e.hasSeverity = false
+	e.source = nil
+	if e.record != nil {
+		*e.record = slog.Record{}
+	}
+	errorXPool.Put(e)
+}
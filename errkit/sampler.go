@@ -0,0 +1,115 @@
+package errkit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Sampler reduces the volume of logged errors by sampling repetitive
+// errors of low severity, errors are grouped by kind and within every tick
+// the first errors of a kind are logged followed by every thereafter-th one,
+// errors with severity of slog.LevelError or higher are always logged i.e with
+// DefaultKindSeverity not found errors are sampled while network, deadline,
+// internal and unknown errors are not
+type Sampler struct {
+	tick       time.Duration
+	first      int
+	thereafter int
+
+	mu       sync.Mutex
+	counters map[string]*sampleCounter
+}
+
+type sampleCounter struct {
+	resetAt time.Time
+	count   int
+}
+
+// NewSampler returns a Sampler logging the first errors of each kind
+// within every tick and every thereafter-th one after that, a thereafter
+// of zero drops all errors of the kind beyond first until the next tick
+//
+//	Example:
+//
+//	// per kind and second log 10 errors then every 100th
+//	sampler := errkit.NewSampler(time.Second, 10, 100)
+func NewSampler(tick time.Duration, first, thereafter int) *Sampler {
+	return &Sampler{
+		tick:       tick,
+		first:      first,
+		thereafter: thereafter,
+		counters:   make(map[string]*sampleCounter),
+	}
+}
+
+// ShouldLog returns true if given error should be logged
+func (s *Sampler) ShouldLog(err error) bool {
+	if err == nil {
+		return false
+	}
+	x := &ErrorX{}
+	parseError(x, err)
+	if x.Severity() >= slog.LevelError {
+		return true
+	}
+	return s.sample(x.Kind().String(), time.Now())
+}
+
+// sample counts an error for the key and returns true if it should be logged
+func (s *Sampler) sample(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.resetAt) {
+		counter = &sampleCounter{resetAt: now.Add(s.tick)}
+		s.counters[key] = counter
+	}
+	counter.count++
+	if counter.count <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (counter.count-s.first)%s.thereafter == 0
+}
+
+// Handler returns a slog.Handler sampling records with an error attribute
+// below slog.LevelError using ShouldLog before passing them to next
+//
+//	Example:
+//
+//	logger := slog.New(sampler.Handler(slog.NewJSONHandler(os.Stderr, nil)))
+func (s *Sampler) Handler(next slog.Handler) slog.Handler {
+	return &samplingHandler{Handler: next, sampler: s}
+}
+
+type samplingHandler struct {
+	slog.Handler
+	sampler *Sampler
+}
+
+// Handle implements slog.Handler
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelError {
+		var err error
+		r.Attrs(func(a slog.Attr) bool {
+			err, _ = a.Value.Any().(error)
+			return err == nil
+		})
+		if err != nil && !h.sampler.ShouldLog(err) {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+// WithGroup implements slog.Handler
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
@@ -0,0 +1,85 @@
+package errkit
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// sentinelKind maps a sentinel error to its error kind
+type sentinelKind struct {
+	target error
+	kind   ErrKind
+}
+
+var (
+	sentinelKindsMu sync.RWMutex
+	// sentinelKinds is the registry of sentinel errors consulted while parsing errors
+	// errors matching a sentinel (using errors.Is) are classified with its kind,
+	// sentinels of other packages ex: sql.ErrNoRows are registered by the
+	// subpackage for that package (see errkit/sqlutil) to keep errkit lean
+	sentinelKinds = []sentinelKind{
+		{target: io.EOF, kind: ErrKindNetworkTemporary},
+		{target: io.ErrUnexpectedEOF, kind: ErrKindNetworkTemporary},
+		{target: os.ErrNotExist, kind: ErrKindNotFound},
+	}
+)
+
+// RegisterSentinelKind registers kind for given sentinel error so that
+// errors matching it (using errors.Is) are classified automatically
+// registering an already registered sentinel overrides its kind
+// and a nil kind removes the sentinel from the registry
+//
+//	Example:
+//
+//	errkit.RegisterSentinelKind(io.EOF, ErrKindMyAppEOF)
+func RegisterSentinelKind(target error, kind ErrKind) {
+	if target == nil {
+		return
+	}
+	sentinelKindsMu.Lock()
+	defer sentinelKindsMu.Unlock()
+
+	for i, v := range sentinelKinds {
+		if v.target == target {
+			if kind == nil {
+				sentinelKinds = append(sentinelKinds[:i], sentinelKinds[i+1:]...)
+			} else {
+				sentinelKinds[i].kind = kind
+			}
+			return
+		}
+	}
+	if kind != nil {
+		sentinelKinds = append(sentinelKinds, sentinelKind{target: target, kind: kind})
+	}
+}
+
+// getSentinelKind returns kind of first registered sentinel matching given error
+func getSentinelKind(err error) ErrKind {
+	sentinelKindsMu.RLock()
+	defer sentinelKindsMu.RUnlock()
+
+	for _, v := range sentinelKinds {
+		if errors.Is(err, v.target) {
+			return v.kind
+		}
+	}
+	return nil
+}
+
+// classifySentinel sets kind of given error if err matches a registered sentinel
+func classifySentinel(to *ErrorX, err error) {
+	kind := getSentinelKind(err)
+	if kind == nil {
+		return
+	}
+	to.traceKind(kind, err.Error())
+	switch {
+	case to.kind == nil:
+		to.kind = kind
+	case !to.kind.Is(kind):
+		to.kind = CombineErrKinds(to.kind, kind)
+	}
+}
@@ -0,0 +1,66 @@
+package errkit
+
+import "log/slog"
+
+// LevelFatal is the severity of errors after which the program can not continue
+// it is higher than all slog levels
+const LevelFatal = slog.Level(12)
+
+var (
+	// DefaultKindSeverity maps error kind ids to their default severity
+	// kinds not present in this map default to slog.LevelError, network and
+	// deadline errors are graded as errors since even temporary ones need
+	// attention once retries are exhausted while errors caused by the caller
+	// (ex: not found) are graded lower
+	// if one intends to add more entries it must be done in init() function
+	// of that package to avoid race conditions
+	DefaultKindSeverity = map[string]slog.Level{
+		ErrKindPanic.String():            LevelFatal,
+		ErrKindInternal.String():         slog.LevelError,
+		ErrKindNetworkPermanent.String(): slog.LevelError,
+		ErrKindUnknown.String():          slog.LevelError,
+		ErrKindNetworkTemporary.String(): slog.LevelError,
+		ErrKindDeadline.String():         slog.LevelError,
+		ErrKindNotFound.String():         slog.LevelInfo,
+	}
+)
+
+// KindSeverity returns the default severity of given error kind
+// for combined kinds the highest severity is returned
+func KindSeverity(kind ErrKind) slog.Level {
+	if kind == nil {
+		return slog.LevelError
+	}
+	if val, ok := kind.(*multiKind); ok {
+		level := slog.LevelDebug
+		for _, k := range val.kinds {
+			level = max(level, KindSeverity(k))
+		}
+		return level
+	}
+	if level, ok := DefaultKindSeverity[kind.String()]; ok {
+		return level
+	}
+	return slog.LevelError
+}
+
+// SetSeverity sets the severity of the error
+// it takes precedence over the default severity derived from kind
+//
+//	Example:
+//
+//	myError.SetSeverity(slog.LevelWarn)
+func (e *ErrorX) SetSeverity(level slog.Level) *ErrorX {
+	e.severity = level
+	e.hasSeverity = true
+	return e
+}
+
+// Severity returns the severity of the error
+// if it was not explicitly set it is derived from the kind
+func (e *ErrorX) Severity() slog.Level {
+	if e.hasSeverity {
+		return e.severity
+	}
+	return KindSeverity(e.Kind())
+}
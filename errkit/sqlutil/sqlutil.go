@@ -0,0 +1,15 @@
+// sqlutil registers the sentinel errors of database/sql with errkit
+// importing it is enough for them to be classified automatically
+//
+//	import _ "github.com/projectdiscovery/utils/errkit/sqlutil"
+package sqlutil
+
+import (
+	"database/sql"
+
+	"github.com/projectdiscovery/utils/errkit"
+)
+
+func init() {
+	errkit.RegisterSentinelKind(sql.ErrNoRows, errkit.ErrKindNotFound)
+}
@@ -0,0 +1,15 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/projectdiscovery/utils/errkit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoRows(t *testing.T) {
+	require.Equal(t, errkit.ErrKindNotFound, errkit.FromError(sql.ErrNoRows).Kind())
+	require.True(t, errkit.IsKind(fmt.Errorf("lookup user: %w", sql.ErrNoRows), errkit.ErrKindNotFound))
+}
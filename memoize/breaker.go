@@ -0,0 +1,131 @@
+package memoize
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// breaker tracks consecutive failures of loaders per key
+//
+// A key is closed while its consecutive failures are below threshold.
+// Once threshold is reached the key opens and calls are short-circuited
+// with the last error until cooldown elapses, then a single trial call
+// is allowed (half-open); success closes the key and failure re-opens it
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu      sync.Mutex
+	states  map[uint64]*breakerState
+	sweptAt time.Time
+}
+
+type breakerState struct {
+	failures int
+	lastErr  error
+	failedAt time.Time
+	openedAt time.Time
+	trial    bool
+}
+
+// WithCircuitBreaker short-circuits calls for a key with the last error
+// after threshold consecutive failures of fn, for the given cooldown
+// after cooldown a single trial call is allowed to probe the loader,
+// failures of a closed key more than cooldown apart are not consecutive
+func WithCircuitBreaker(threshold int, cooldown time.Duration) MemoizeOption {
+	return func(m *Memoizer) error {
+		if threshold <= 0 {
+			return errors.New("circuit breaker threshold must be positive")
+		}
+		if cooldown <= 0 {
+			return errors.New("circuit breaker cooldown must be positive")
+		}
+		m.breaker = &breaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			states:    make(map[uint64]*breakerState),
+		}
+		return nil
+	}
+}
+
+// allow returns the last error if the key is open, when cooldown has
+// elapsed it lets a single trial call through and returns nil
+func (b *breaker) allow(hash uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.states[hash]
+	if !ok {
+		return nil
+	}
+	if state.failures < b.threshold {
+		if b.recovered(state, time.Now()) {
+			delete(b.states, hash)
+		}
+		return nil
+	}
+	if state.trial || time.Since(state.openedAt) < b.cooldown {
+		return state.lastErr
+	}
+	state.trial = true
+	return nil
+}
+
+// record updates the state of the key with the outcome of a call
+func (b *breaker) record(hash uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		delete(b.states, hash)
+		return
+	}
+
+	state, ok := b.states[hash]
+	if !ok {
+		state = &breakerState{}
+		b.states[hash] = state
+	}
+	now := time.Now()
+	state.failures++
+	state.lastErr = err
+	state.failedAt = now
+	state.trial = false
+	if state.failures >= b.threshold {
+		state.openedAt = now
+	}
+	b.sweep(now)
+}
+
+// release ends a trial call that did not record an outcome i.e. the
+// loader panicked, so that the next call after cooldown can probe again
+func (b *breaker) release(hash uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state, ok := b.states[hash]; ok {
+		state.trial = false
+	}
+}
+
+// recovered reports whether a closed key has not failed for a cooldown
+// so its failures are no longer consecutive and its state can be dropped
+func (b *breaker) recovered(state *breakerState, now time.Time) bool {
+	return state.failures < b.threshold && now.Sub(state.failedAt) >= b.cooldown
+}
+
+// sweep drops the state of recovered keys at most once per cooldown
+// as keys that stop being called would otherwise be tracked forever
+func (b *breaker) sweep(now time.Time) {
+	if now.Sub(b.sweptAt) < b.cooldown {
+		return
+	}
+	b.sweptAt = now
+	for hash, state := range b.states {
+		if b.recovered(state, now) {
+			delete(b.states, hash)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package memoize
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// byteBudget accounts the estimated size of cached values and
+// evicts least recently used entries when the budget is exceeded
+type byteBudget struct {
+	maxBytes int64
+	sizeOf   func(v interface{}) int64
+
+	mu   sync.Mutex
+	used int64
+	// lru holds *budgetEntry, front is the most recently used
+	lru     *list.List
+	entries map[uint64]*list.Element
+}
+
+type budgetEntry struct {
+	hash uint64
+	size int64
+}
+
+// WithMaxBytes limits the cache by the summed size of values estimated with sizeOf
+// when the budget is exceeded entries are evicted in least recently used order
+// i.e entries neither set nor returned as a hit for the longest time go first,
+// values larger than the whole budget are not cached
+//
+// It can be combined with WithMaxSize to limit the number of entries as well,
+// pinned values are not accounted
+//
+//	m, _ := memoize.New(memoize.WithMaxBytes(64<<20, func(v interface{}) int64 {
+//		return int64(len(v.([]byte)))
+//	}))
+func WithMaxBytes(n int64, sizeOf func(v interface{}) int64) MemoizeOption {
+	return func(m *Memoizer) error {
+		if n <= 0 {
+			return errors.New("max bytes must be positive")
+		}
+		if sizeOf == nil {
+			return errors.New("sizeOf function is required")
+		}
+		m.budget = &byteBudget{
+			maxBytes: n,
+			sizeOf:   sizeOf,
+			lru:      list.New(),
+			entries:  make(map[uint64]*list.Element),
+		}
+		return nil
+	}
+}
+
+// add accounts given value for the key and returns keys to evict
+// to stay within the budget, the key itself is evicted last
+func (b *byteBudget) add(hash uint64, value interface{}) []uint64 {
+	size := b.sizeOf(value)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.entries[hash]; ok {
+		entry := e.Value.(*budgetEntry)
+		b.used += size - entry.size
+		entry.size = size
+		b.lru.MoveToFront(e)
+	} else {
+		b.entries[hash] = b.lru.PushFront(&budgetEntry{hash: hash, size: size})
+		b.used += size
+	}
+
+	var evicted []uint64
+	for b.used > b.maxBytes && b.lru.Len() > 0 {
+		entry := b.lru.Remove(b.lru.Back()).(*budgetEntry)
+		delete(b.entries, entry.hash)
+		b.used -= entry.size
+		evicted = append(evicted, entry.hash)
+	}
+	return evicted
+}
+
+// touch marks the key as most recently used
+func (b *byteBudget) touch(hash uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.entries[hash]; ok {
+		b.lru.MoveToFront(e)
+	}
+}
+
+// remove releases the size accounted for the key
+func (b *byteBudget) remove(hash uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.entries[hash]; ok {
+		b.used -= b.lru.Remove(e).(*budgetEntry).size
+		delete(b.entries, hash)
+	}
+}
+
+// usedBytes returns the summed estimated size of accounted values
+func (b *byteBudget) usedBytes() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.used
+}
@@ -1,15 +1,229 @@
 package main
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/projectdiscovery/utils/memoize"
 )
 
+var (
+	src        = flag.String("src", "../tests/test.go", "go source file or directory")
+	recursive  = flag.Bool("r", false, "recursively generate memoized versions of all packages under src")
+	pkg        = flag.String("pkg", "memo", "package name of the generated code")
+	unexported = flag.Bool("unexported", false, "include unexported functions and generate wrappers in the source package")
+	ignoreCase = flag.Bool("ignorecase", false, "match the @memo directive case-insensitively")
+	register   = flag.Bool("register", false, "register generated functions to be invalidated with memoize.InvalidateAll")
+)
+
 func main() {
-	out, err := memoize.File(memoize.PackageTemplate, "../tests/test.go", "test")
+	flag.Parse()
+
+	if !*recursive {
+		out, err := generateFile(*src, *pkg, genOptions{Unexported: *unexported, IgnoreCase: *ignoreCase, Register: *register})
+		if err != nil {
+			panic(err)
+		}
+		log.Println(string(out))
+		return
+	}
+
+	written, err := generateTree(*src, *pkg, genOptions{Unexported: *unexported, IgnoreCase: *ignoreCase, Register: *register})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range written {
+		log.Printf("wrote %s\n", path)
+	}
+	log.Printf("generated %d files\n", len(written))
+}
+
+// genOptions are the code generation flags
+type genOptions struct {
+	Unexported bool
+	IgnoreCase bool
+	Register   bool
+}
+
+// srcOptions returns the code generation options for given flags
+func (o genOptions) srcOptions() []memoize.SrcOption {
+	var options []memoize.SrcOption
+	if o.Unexported {
+		options = append(options, memoize.WithUnexported())
+	}
+	if o.IgnoreCase {
+		options = append(options, memoize.WithCaseInsensitiveDirective())
+	}
+	if o.Register {
+		options = append(options, memoize.WithRegistration())
+	}
+	return options
+}
+
+// generateFile generates memoized version of given source file
+func generateFile(path, pkgName string, opts genOptions) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := memoize.Src(memoize.PackageTemplate, path, data, pkgName, opts.srcOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	logWarnings(path, data, pkgName, opts)
+	return out, nil
+}
+
+// generateTree walks the root directory and generates memoized version of
+// every source file containing functions tagged with @memo directives, the
+// output is written to <package dir>/<pkgName>/<file>_memo.go along with the
+// helpers shared by the files of the package in <package dir>/<pkgName>/memo_helpers.go
+// and paths of written files are returned, with unexported the output is
+// written to <package dir> instead
+func generateTree(root, pkgName string, opts genOptions) ([]string, error) {
+	// tagged source files grouped by output directory in walk order
+	var outDirs []string
+	packages := map[string][]taggedFile{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		dir, base := filepath.Split(path)
+		// respect go:build constraints and GOOS/GOARCH file suffixes
+		if match, err := build.Default.MatchFile(dir, base); err != nil || !match {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// directives are matched case-insensitively here so that near misses are reported
+		if !bytes.Contains(bytes.ToLower(data), []byte("@memo")) {
+			return nil
+		}
+		if isGenerated(path, data) {
+			return nil
+		}
+
+		logWarnings(path, data, pkgName, opts)
+		fileData, err := memoize.Parse(path, data, pkgName, opts.srcOptions()...)
+		if err != nil {
+			return err
+		}
+		if len(fileData.Functions) == 0 {
+			return nil
+		}
+
+		outDir := filepath.Join(dir, pkgName)
+		if opts.Unexported {
+			outDir = filepath.Clean(dir)
+		}
+		if _, ok := packages[outDir]; !ok {
+			outDirs = append(outDirs, outDir)
+		}
+		packages[outDir] = append(packages[outDir], taggedFile{path: path, data: data, fileData: fileData})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, outDir := range outDirs {
+		paths, err := generatePackage(outDir, pkgName, packages[outDir], opts)
+		written = append(written, paths...)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// taggedFile is a source file containing functions tagged with @memo directives
+type taggedFile struct {
+	path     string
+	data     []byte
+	fileData *memoize.FileData
+}
+
+// generatePackage writes the memoized version of given files of a package
+// to outDir along with the helpers shared by them and returns written paths
+func generatePackage(outDir, pkgName string, files []taggedFile, opts genOptions) ([]string, error) {
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	var fileDatas []*memoize.FileData
+	for _, file := range files {
+		base := filepath.Base(file.path)
+		outPath := filepath.Join(outDir, strings.TrimSuffix(base, ".go")+"_memo.go")
+		// source path is kept so that other files of the source package are found
+		options := append(opts.srcOptions(), memoize.WithoutHelpers(), memoize.WithOutputPath(outPath))
+		out, err := memoize.Src(memoize.PackageTemplate, file.path, file.data, pkgName, options...)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(outPath, out, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, outPath)
+		fileDatas = append(fileDatas, file.fileData)
+	}
+
+	helpersPath := filepath.Join(outDir, "memo_helpers.go")
+	if data, err := os.ReadFile(helpersPath); err == nil && !isGenerated(helpersPath, data) {
+		return written, fmt.Errorf("%s: refusing to overwrite a file that was not generated", helpersPath)
+	}
+	out, err := memoize.Helpers(memoize.PackageTemplate, helpersPath, fileDatas...)
+	if err != nil {
+		return written, err
+	}
+	if err := os.WriteFile(helpersPath, out, 0644); err != nil {
+		return written, err
+	}
+	return append(written, helpersPath), nil
+}
+
+// logWarnings logs the warnings of tagged functions of the source file
+func logWarnings(path string, data []byte, pkgName string, opts genOptions) {
+	fileData, err := memoize.Parse(path, data, pkgName, opts.srcOptions()...)
+	if err != nil {
+		return
+	}
+	for _, warning := range fileData.Validate() {
+		log.Printf("%s: warning: %s\n", path, warning)
+	}
+}
+
+// isGenerated checks if the source file contains the generated code header
+func isGenerated(path string, data []byte) bool {
+	node, err := parser.ParseFile(token.NewFileSet(), path, data, parser.PackageClauseOnly|parser.ParseComments)
 	if err != nil {
-		panic(err)
+		return false
 	}
-	log.Println(string(out))
+	return ast.IsGenerated(node)
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTree(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module example.com/memotest\n\ngo 1.21\n",
+		"foo/foo.go": `package foo
+
+// @memo
+func Foo(a string) string {
+	return a
+}
+`,
+		"foo/bar/bar.go": `package bar
+
+// @memo
+func Bar() int {
+	return 1
+}
+`,
+		"foo/ignored.go": `//go:build ignore
+
+package foo
+
+// @memo
+func Ignored() int {
+	return 1
+}
+`,
+		"foo/generated.go": `// Code generated by tool. DO NOT EDIT.
+
+package foo
+
+// @memo
+func Generated() int {
+	return 1
+}
+`,
+		"foo/nomemo.go": `package foo
+
+func NoMemo() int {
+	return 1
+}
+`,
+		"foo/mention.go": `package foo
+
+// Mention is not tagged but mentions @memo in its doc
+func Mention() int {
+	return 1
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.Nil(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	// imports of generated code are resolved relative to the working directory
+	t.Chdir(root)
+
+	written, err := generateTree(root, "memo", genOptions{})
+	require.Nil(t, err)
+	require.ElementsMatch(t, []string{
+		filepath.Join(root, "foo", "memo", "foo_memo.go"),
+		filepath.Join(root, "foo", "memo", "memo_helpers.go"),
+		filepath.Join(root, "foo", "bar", "memo", "bar_memo.go"),
+		filepath.Join(root, "foo", "bar", "memo", "memo_helpers.go"),
+	}, written)
+
+	data, err := os.ReadFile(filepath.Join(root, "foo", "memo", "foo_memo.go"))
+	require.Nil(t, err)
+	require.Contains(t, string(data), `"example.com/memotest/foo"`)
+
+	// generated files must not be picked up again
+	written, err = generateTree(root, "memo", genOptions{})
+	require.Nil(t, err)
+	require.Len(t, written, 4)
+}
+
+func TestGenerateTreeMultipleFiles(t *testing.T) {
+	// the tree lives in the module so that the generated code can be built
+	root, err := os.MkdirTemp(".", "tree")
+	require.Nil(t, err)
+	defer func(root string) {
+		_ = os.RemoveAll(root)
+	}(root)
+
+	files := map[string]string{
+		"a.go": `package multi
+
+import "strings"
+
+// @memo metrics
+func A(a string) string {
+	return strings.ToUpper(a)
+}
+`,
+		"b.go": `package multi
+
+// @memo
+func B(a string) (string, error) {
+	return a + sibling, nil
+}
+
+// @memo
+func BOnce() int {
+	return 1
+}
+`,
+		"sibling.go": `package multi
+
+const sibling = "b"
+`,
+	}
+	// imports are only resolved for directories named after the package
+	root = filepath.Join(root, "multi")
+	require.Nil(t, os.Mkdir(root, os.ModePerm))
+	for name, content := range files {
+		require.Nil(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+
+	for _, opts := range []genOptions{{}, {Unexported: true}} {
+		written, err := generateTree(root, "memo", opts)
+		require.Nil(t, err)
+		require.Len(t, written, 3)
+
+		// helpers are declared once per package
+		helpers, err := os.ReadFile(written[2])
+		require.Nil(t, err)
+		require.Contains(t, string(helpers), "func hash(")
+		require.Contains(t, string(helpers), "func onMetrics(")
+		for _, path := range written[:2] {
+			data, err := os.ReadFile(path)
+			require.Nil(t, err)
+			require.NotContains(t, string(data), "func hash(")
+			require.NotContains(t, string(data), "var cache")
+		}
+
+		pkg := "./" + filepath.ToSlash(filepath.Dir(written[0]))
+		output, err := exec.Command("go", "build", pkg).CombinedOutput()
+		require.Nil(t, err, string(output))
+
+		for _, path := range written {
+			require.Nil(t, os.Remove(path))
+		}
+	}
+}
+
+func TestGenerateFileUnexported(t *testing.T) {
+	path := filepath.Join("testdata", "unexported.go")
+	out, err := generateFile(path, "memo", genOptions{Unexported: true})
+	require.Nil(t, err)
+
+	// wrappers are generated in the source package and call the functions unqualified
+	require.Contains(t, string(out), "package fixture")
+	require.Contains(t, string(out), "func memoizedFetch(a string) (string, error)")
+	require.Contains(t, string(out), "func MemoizedCount(a string) int")
+	require.Contains(t, string(out), "fetch(a)")
+	require.NotContains(t, string(out), "fixture.fetch")
+
+	src, err := os.ReadFile(path)
+	require.Nil(t, err)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for name, data := range map[string][]byte{path: src, "unexported_memo.go": out} {
+		file, err := parser.ParseFile(fset, name, data, 0)
+		require.Nil(t, err)
+		files = append(files, file)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("fixture", fset, files, nil)
+	require.Nil(t, err, "generated code must compile with the source package")
+
+	// without the flag unexported functions are skipped
+	out, err = generateFile(path, "memo", genOptions{})
+	require.Nil(t, err)
+	require.NotContains(t, string(out), "fetch")
+	require.Contains(t, string(out), "func Count(a string) int")
+}
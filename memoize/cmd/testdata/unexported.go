@@ -0,0 +1,15 @@
+package fixture
+
+import "strings"
+
+// @memo
+func fetch(a string) (string, error) {
+	return strings.ToUpper(a), nil
+}
+
+// @memo
+func Count(a string) int {
+	return len(a)
+}
+
+func notTagged() {}
@@ -0,0 +1,49 @@
+package memoize
+
+import (
+	"sync"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// collisionCheck tracks which computation first used a key
+type collisionCheck struct {
+	mu   sync.Mutex
+	tags map[uint64]string
+	// warn is invoked when a key is reused by a different computation
+	warn func(funcHash, tag, otherTag string)
+}
+
+// WithDebugCollisionCheck warns when a key is used by different computations
+// the computation is identified by the tag given to DoTagged or by the code
+// of fn passed to Do and DoIf, closures created from the same function
+// literal share identity and are considered the same computation
+//
+// This is meant as a development time safety net, it adds a lookup of the
+// function name on every call and keeps the tag of every key ever used
+// in memory irrespective of the max size of the cache
+func WithDebugCollisionCheck() MemoizeOption {
+	return func(m *Memoizer) error {
+		m.collision = &collisionCheck{
+			tags: make(map[uint64]string),
+			warn: func(funcHash, tag, otherTag string) {
+				gologger.Warning().Msgf("memoize: key %q is used by %s and %s", funcHash, tag, otherTag)
+			},
+		}
+		return nil
+	}
+}
+
+// check records tag for the key and warns if it was used with another tag
+func (c *collisionCheck) check(hash uint64, funcHash, tag string) {
+	c.mu.Lock()
+	prev, ok := c.tags[hash]
+	if !ok {
+		c.tags[hash] = tag
+	}
+	c.mu.Unlock()
+
+	if ok && prev != tag {
+		c.warn(funcHash, prev, tag)
+	}
+}
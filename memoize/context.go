@@ -0,0 +1,30 @@
+package memoize
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying a new Memoizer created with given options
+// the memoizer is sized with WithMaxSize(1000) unless overridden by options
+// it is used by functions tagged with "@memo scope=context" to cache results
+// within the lifetime of ctx (ex: a single request) instead of globally
+//
+//	ctx, err := memoize.NewContext(r.Context())
+//	if err != nil {
+//		return err
+//	}
+//	v := memo.Expensive(ctx, arg)
+func NewContext(ctx context.Context, options ...MemoizeOption) (context.Context, error) {
+	m, err := New(append([]MemoizeOption{WithMaxSize(1000)}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, contextKey{}, m), nil
+}
+
+// FromContext returns the Memoizer attached to ctx by NewContext
+// or nil if there is none
+func FromContext(ctx context.Context) *Memoizer {
+	m, _ := ctx.Value(contextKey{}).(*Memoizer)
+	return m
+}
@@ -0,0 +1,25 @@
+package memoize
+
+import "sync"
+
+var (
+	defaultOnce     sync.Once
+	defaultMemoizer *Memoizer
+)
+
+// Default returns the process wide Memoizer shared by all callers
+// it is lazily created with WithMaxSize(1000) on first use, callers
+// needing other options or isolation must construct their own with New
+func Default() *Memoizer {
+	defaultOnce.Do(func() {
+		defaultMemoizer, _ = New(WithMaxSize(1000))
+	})
+	return defaultMemoizer
+}
+
+// Do calls Do of the Default Memoizer
+//
+//	v, err, _ := memoize.Do("config", loadConfig)
+func Do(funcHash string, fn func() (interface{}, error)) (interface{}, error, bool) {
+	return Default().Do(funcHash, fn)
+}
@@ -0,0 +1,38 @@
+package memoize
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// WithExpirationJitter randomizes the ttl of every expiring entry i.e stored by
+// Do with the default ttl of WithTTL or set with SetWithTTL within ±fraction
+// of its ttl ex: with 0.1 a ttl of 10m expires anywhere between 9m and 11m,
+// this prevents entries populated together from expiring together and
+// causing a synchronized recomputation stampede
+//
+// The default fraction is 0 i.e no jitter, fraction must be in [0, 1)
+//
+// Expired entries are not refreshed ahead of time, they are recomputed by the
+// next Do for the key, so the jitter spreads those recomputations over time
+// a refresh-ahead scheme built on top of the ttl must account for entries
+// expiring up to fraction*ttl earlier than the base ttl
+func WithExpirationJitter(fraction float64) MemoizeOption {
+	return func(m *Memoizer) error {
+		if fraction < 0 || fraction >= 1 {
+			return errors.New("expiration jitter must be in [0, 1)")
+		}
+		m.jitter = fraction
+		return nil
+	}
+}
+
+// jitterTTL returns ttl randomized within ±jitter of its value
+func (m *Memoizer) jitterTTL(ttl time.Duration) time.Duration {
+	if m.jitter == 0 || ttl <= 0 {
+		return ttl
+	}
+	delta := (rand.Float64()*2 - 1) * m.jitter * float64(ttl)
+	return ttl + time.Duration(delta)
+}
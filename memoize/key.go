@@ -0,0 +1,163 @@
+package memoize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// HashArgs returns a stable key for given arguments derived from their type
+// and value so that arguments of different types do not collide, ex: 1 and "1"
+// result in different keys
+//
+// The key is deterministic for values made of basic types, arrays, slices,
+// structs (including unexported fields) and maps (encoded with sorted keys),
+// pointers, channels and funcs are represented by their address i.e pointers
+// to equal values result in different keys
+//
+// Values are normalized so that equal arguments result in the same key:
+//   - time.Time is encoded as UnixNano i.e the monotonic clock reading and
+//     location are ignored and times of the same instant are equal
+//   - all NaN floats are encoded as NaN i.e calls with NaN share an entry
+//     unlike map keys where NaN is never equal to itself
+func HashArgs(args ...any) string {
+	h := sha256.New()
+	for _, arg := range args {
+		writeArg(h, arg)
+		_, _ = io.WriteString(h, "\x00")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeArg writes the normalized encoding of given argument to w
+func writeArg(w io.Writer, arg any) {
+	if arg == nil {
+		_, _ = io.WriteString(w, "nil")
+		return
+	}
+	// addressable copy allows reading unexported fields of type time.Time
+	v := reflect.New(reflect.TypeOf(arg)).Elem()
+	v.Set(reflect.ValueOf(arg))
+	_, _ = io.WriteString(w, v.Type().String()+":")
+	writeValue(w, v)
+}
+
+// writeValue writes the normalized encoding of given value to w
+func writeValue(w io.Writer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		_, _ = io.WriteString(w, "nil")
+	case reflect.Bool:
+		_, _ = io.WriteString(w, strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, _ = io.WriteString(w, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, _ = io.WriteString(w, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		_, _ = io.WriteString(w, formatFloat(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		_, _ = io.WriteString(w, "("+formatFloat(real(c))+","+formatFloat(imag(c))+")")
+	case reflect.String:
+		_, _ = io.WriteString(w, strconv.Quote(v.String()))
+	case reflect.Pointer, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		_, _ = fmt.Fprintf(w, "%#x", v.Pointer())
+	case reflect.Interface:
+		if v.IsNil() {
+			_, _ = io.WriteString(w, "nil")
+			return
+		}
+		_, _ = io.WriteString(w, v.Elem().Type().String()+":")
+		writeValue(w, v.Elem())
+	case reflect.Array, reflect.Slice:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			_, _ = io.WriteString(w, "nil")
+			return
+		}
+		_, _ = io.WriteString(w, "[")
+		for i := 0; i < v.Len(); i++ {
+			writeValue(w, v.Index(i))
+			_, _ = io.WriteString(w, ",")
+		}
+		_, _ = io.WriteString(w, "]")
+	case reflect.Map:
+		if v.IsNil() {
+			_, _ = io.WriteString(w, "nil")
+			return
+		}
+		entries := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var entry strings.Builder
+			writeValue(&entry, iter.Key())
+			_, _ = io.WriteString(&entry, ":")
+			writeValue(&entry, iter.Value())
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		_, _ = io.WriteString(w, "{")
+		for _, entry := range entries {
+			_, _ = io.WriteString(w, entry+",")
+		}
+		_, _ = io.WriteString(w, "}")
+	case reflect.Struct:
+		if v.Type() == timeType {
+			if t, ok := timeValue(v); ok {
+				_, _ = io.WriteString(w, strconv.FormatInt(t.UnixNano(), 10))
+				return
+			}
+		}
+		_, _ = io.WriteString(w, "{")
+		for i := 0; i < v.NumField(); i++ {
+			_, _ = io.WriteString(w, v.Type().Field(i).Name+":")
+			writeValue(w, v.Field(i))
+			_, _ = io.WriteString(w, ",")
+		}
+		_, _ = io.WriteString(w, "}")
+	}
+}
+
+// timeValue returns the time.Time held by given value including
+// values of unexported fields as long as they are addressable
+func timeValue(v reflect.Value) (time.Time, bool) {
+	if v.CanInterface() {
+		return v.Interface().(time.Time), true
+	}
+	if v.CanAddr() {
+		return *(*time.Time)(unsafe.Pointer(v.UnsafeAddr())), true
+	}
+	return time.Time{}, false
+}
+
+// formatFloat formats given float with all NaN values formatted the same
+func formatFloat(f float64) string {
+	if math.IsNaN(f) {
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// DoKey is like Do but the key is derived from given value using HashArgs
+// which allows passing a struct of parameters directly, see HashArgs for
+// the values resulting in a deterministic key and applied normalizations
+//
+//	type query struct {
+//		Host string
+//		Port int
+//	}
+//	v, err, _ := m.DoKey(query{Host: host, Port: port}, func() (interface{}, error) {
+//		return dial(host, port)
+//	})
+func (m *Memoizer) DoKey(key any, fn func() (interface{}, error)) (interface{}, error, bool) {
+	return m.Do(HashArgs(key), fn)
+}
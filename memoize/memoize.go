@@ -2,17 +2,25 @@ package memoize
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/build/constraint"
 	"go/format"
 	"go/parser"
 	"go/printer"
 	"go/token"
 	"go/types"
 	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/Mzack9999/gcache"
 	"github.com/cespare/xxhash"
@@ -24,23 +32,79 @@ import (
 type Memoizer struct {
 	cache gcache.Cache[uint64, interface{}]
 	group singleflight.Group[uint64]
+
+	pinnedMu     sync.RWMutex
+	pinnedKeys   map[uint64]struct{}
+	pinnedValues map[uint64]interface{}
+
+	breaker   *breaker
+	collision *collisionCheck
+	budget    *byteBudget
+	jitter    float64
+	// ttl is the expiration of entries stored without an explicit ttl
+	ttl time.Duration
+	// noSingleflight disables deduplication of concurrent calls
+	noSingleflight bool
+	// cloner copies values before they are returned
+	cloner func(v interface{}) interface{}
 }
 
 type MemoizeOption func(m *Memoizer) error
 
 func WithMaxSize(size int) MemoizeOption {
 	return func(m *Memoizer) error {
-		m.cache = gcache.
-			New[uint64, interface{}](size).
-			EvictedFunc(func(k uint64, _ interface{}) {
-				m.group.Forget(k)
-			}).
-			Build()
+		m.cache = m.newCache(size)
+		return nil
+	}
+}
 
+// WithTTL sets the default expiration of cached entries i.e values
+// computed by Do and its variants and given to Set and Warm, it is
+// randomized by WithExpirationJitter if configured, zero means no expiration
+func WithTTL(ttl time.Duration) MemoizeOption {
+	return func(m *Memoizer) error {
+		if ttl < 0 {
+			return errors.New("ttl must not be negative")
+		}
+		m.ttl = ttl
 		return nil
 	}
 }
 
+// WithoutSingleflight disables deduplication of concurrent calls for the same key
+// on a miss fn is called directly and its value cached, this avoids the lock
+// contention of the call group for cheap and idempotent functions at the cost
+// of possible duplicate executions of fn by concurrent callers, it should not
+// be used for expensive functions or functions with side effects
+func WithoutSingleflight() MemoizeOption {
+	return func(m *Memoizer) error {
+		m.noSingleflight = true
+		return nil
+	}
+}
+
+// WithValueCloner sets a function copying values returned by Do and its variants
+// cached values are shared by all callers so mutating a returned slice or map
+// would otherwise modify the cached value as well, cloning trades CPU and
+// allocations on every call (hits included) for that safety
+//
+// Values given to Set, SetWithTTL and Warm are cached as is
+//
+//	m, _ := memoize.New(memoize.WithValueCloner(func(v interface{}) interface{} {
+//		return slices.Clone(v.([]string))
+//	}))
+func WithValueCloner(cloner func(v interface{}) interface{}) MemoizeOption {
+	return func(m *Memoizer) error {
+		if cloner == nil {
+			return errors.New("cloner function is required")
+		}
+		m.cloner = cloner
+		return nil
+	}
+}
+
+// New returns a Memoizer configured with given options
+// without WithMaxSize the number of cached entries is not limited
 func New(options ...MemoizeOption) (*Memoizer, error) {
 	m := &Memoizer{}
 	for _, option := range options {
@@ -48,51 +112,458 @@ func New(options ...MemoizeOption) (*Memoizer, error) {
 			return nil, err
 		}
 	}
+	if m.cache == nil {
+		// size is only limited by the byte budget if any
+		m.cache = m.newCache(0)
+	}
 
 	return m, nil
 }
 
+// newCache returns a cache holding at most size entries, zero means unlimited
+func (m *Memoizer) newCache(size int) gcache.Cache[uint64, interface{}] {
+	evicted := func(k uint64, _ interface{}) {
+		m.group.Forget(k)
+		if m.budget != nil {
+			m.budget.remove(k)
+		}
+	}
+	return gcache.
+		New[uint64, interface{}](size).
+		EvictedFunc(evicted).
+		PurgeVisitorFunc(evicted).
+		Build()
+}
+
+// Purge removes all cached values so that subsequent calls compute them again
+// values of pinned keys are removed as well while the keys stay pinned
+func (m *Memoizer) Purge() {
+	m.pinnedMu.Lock()
+	defer m.pinnedMu.Unlock()
+
+	clear(m.pinnedValues)
+	if m.cache != nil {
+		m.cache.Purge()
+	}
+}
+
+// Pin marks the given key as immune to size eviction
+// values of pinned keys are stored outside of the cache
+// and do not count towards the max size budget, if the key
+// is already cached its value is moved out of the cache,
+// the key stays pinned until Unpin is called for it
+func (m *Memoizer) Pin(funcHash string) {
+	hash := xxhash.Sum64String(funcHash)
+
+	m.pinnedMu.Lock()
+	defer m.pinnedMu.Unlock()
+
+	if m.pinnedKeys == nil {
+		m.pinnedKeys = make(map[uint64]struct{})
+		m.pinnedValues = make(map[uint64]interface{})
+	}
+	m.pinnedKeys[hash] = struct{}{}
+
+	if value, err := m.cache.GetIFPresent(hash); err == nil {
+		m.pinnedValues[hash] = value
+		m.cache.Remove(hash)
+	}
+}
+
+// Unpin makes the given pinned key subject to size eviction again
+// its value if any is moved back into the cache
+func (m *Memoizer) Unpin(funcHash string) {
+	hash := xxhash.Sum64String(funcHash)
+
+	m.pinnedMu.Lock()
+	defer m.pinnedMu.Unlock()
+
+	if _, ok := m.pinnedKeys[hash]; !ok {
+		return
+	}
+	delete(m.pinnedKeys, hash)
+	if value, ok := m.pinnedValues[hash]; ok {
+		delete(m.pinnedValues, hash)
+		m.store(hash, value, 0)
+	}
+}
+
+func (m *Memoizer) getPinned(hash uint64) (interface{}, bool) {
+	m.pinnedMu.RLock()
+	defer m.pinnedMu.RUnlock()
+
+	value, ok := m.pinnedValues[hash]
+	return value, ok
+}
+
+// set stores the value in pinned store if the key is pinned
+// otherwise in the cache, a ttl of zero means the default ttl
+// only stores of pinned keys are exclusive, others share the read
+// lock which keeps keys from being pinned while they are stored
+func (m *Memoizer) set(hash uint64, value interface{}, ttl time.Duration) {
+	m.pinnedMu.RLock()
+	if _, ok := m.pinnedKeys[hash]; !ok {
+		defer m.pinnedMu.RUnlock()
+		m.store(hash, value, ttl)
+		return
+	}
+	m.pinnedMu.RUnlock()
+
+	m.pinnedMu.Lock()
+	defer m.pinnedMu.Unlock()
+
+	m.setLocked(hash, value, ttl)
+}
+
+// setLocked is like set but the caller must hold pinnedMu
+func (m *Memoizer) setLocked(hash uint64, value interface{}, ttl time.Duration) {
+	if _, ok := m.pinnedKeys[hash]; ok {
+		m.pinnedValues[hash] = value
+		return
+	}
+	m.store(hash, value, ttl)
+}
+
+// store stores the value in the cache, the caller must hold pinnedMu
+// and the key must not be pinned
+func (m *Memoizer) store(hash uint64, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = m.ttl
+	}
+	if ttl > 0 {
+		_ = m.cache.SetWithExpire(hash, value, m.jitterTTL(ttl))
+	} else {
+		_ = m.cache.Set(hash, value)
+	}
+	if m.budget != nil {
+		for _, evicted := range m.budget.add(hash, value) {
+			m.cache.Remove(evicted)
+		}
+	}
+}
+
+// Set populates the entry for the key with given value
+// a subsequent Do for the key returns the value as a hit
+func (m *Memoizer) Set(funcHash string, value interface{}) {
+	m.set(xxhash.Sum64String(funcHash), value, 0)
+}
+
+// Warm populates the cache with given entries without calling any loader
+// ex: to restore a snapshot at startup, it is faster than calling Set for
+// every entry, size limits still apply so if entries exceed the max size
+// or byte budget some of them are evicted in no particular order
+func (m *Memoizer) Warm(entries map[string]interface{}) {
+	m.pinnedMu.Lock()
+	defer m.pinnedMu.Unlock()
+
+	for funcHash, value := range entries {
+		m.setLocked(xxhash.Sum64String(funcHash), value, 0)
+	}
+}
+
+// SetWithTTL is like Set but the entry expires after given ttl
+// randomized by WithExpirationJitter if configured, pinned keys are not subject to expiration
+func (m *Memoizer) SetWithTTL(funcHash string, value interface{}, ttl time.Duration) {
+	m.set(xxhash.Sum64String(funcHash), value, ttl)
+}
+
+// Do returns the cached value for the key or calls fn to compute it
+// errors are not cached, with a circuit breaker configured calls for
+// an open key return the last error without calling fn and report a hit
 func (m *Memoizer) Do(funcHash string, fn func() (interface{}, error)) (interface{}, error, bool) {
+	return m.DoIf(funcHash, nil, fn)
+}
+
+// DoIf is like Do but the value returned by fn is only cached if
+// shouldCache returns true for it, a nil shouldCache caches all values
+// this allows skipping sentinel or empty values that should be recomputed
+func (m *Memoizer) DoIf(funcHash string, shouldCache func(v interface{}) bool, fn func() (interface{}, error)) (interface{}, error, bool) {
+	return m.do(funcHash, "", shouldCache, fn)
+}
+
+// DoTagged is like Do but tag identifies the computation of fn
+// for the collision check enabled with WithDebugCollisionCheck
+func (m *Memoizer) DoTagged(funcHash, tag string, fn func() (interface{}, error)) (interface{}, error, bool) {
+	return m.do(funcHash, tag, nil, fn)
+}
+
+func (m *Memoizer) do(funcHash, tag string, shouldCache func(v interface{}) bool, fn func() (interface{}, error)) (interface{}, error, bool) {
+	value, err, cached := m.doShared(funcHash, tag, shouldCache, fn)
+	if m.cloner != nil && value != nil {
+		value = m.cloner(value)
+	}
+	return value, err, cached
+}
+
+// doShared is like do but returns values shared with the cache and other callers
+func (m *Memoizer) doShared(funcHash, tag string, shouldCache func(v interface{}) bool, fn func() (interface{}, error)) (interface{}, error, bool) {
 	hash := xxhash.Sum64String(funcHash)
 
+	if m.collision != nil {
+		if tag == "" {
+			tag = funcIdentity(fn)
+		}
+		m.collision.check(hash, funcHash, tag)
+	}
+
+	if value, ok := m.getPinned(hash); ok {
+		return value, nil, true
+	}
+
 	if value, err := m.cache.GetIFPresent(hash); !errors.Is(err, gcache.KeyNotFoundError) {
+		if m.budget != nil {
+			m.budget.touch(hash)
+		}
 		return value, err, true
 	}
 
-	value, err, _ := m.group.Do(hash, func() (interface{}, error) {
-		data, err := fn()
-
-		if err == nil {
-			_ = m.cache.Set(hash, data)
+	if m.breaker != nil {
+		if err := m.breaker.allow(hash); err != nil {
+			return nil, err, true
 		}
+	}
+
+	if m.noSingleflight {
+		value, err := m.load(hash, shouldCache, fn)
+		return value, err, false
+	}
 
-		return data, err
+	value, err, _ := m.group.Do(hash, func() (interface{}, error) {
+		// re-check as a concurrent call might have populated
+		// the cache after the lookup above but before this call
+		if value, ok := m.getPinned(hash); ok {
+			return value, nil
+		}
+		if value, err := m.cache.GetIFPresent(hash); !errors.Is(err, gcache.KeyNotFoundError) {
+			return value, err
+		}
+		return m.load(hash, shouldCache, fn)
 	})
 
 	return value, err, false
 }
 
-func File(tpl, sourceFile, packageName string) ([]byte, error) {
+// load calls fn and caches its value if there is no error and shouldCache allows it
+func (m *Memoizer) load(hash uint64, shouldCache func(v interface{}) bool, fn func() (interface{}, error)) (interface{}, error) {
+	if m.breaker != nil {
+		// a panicking trial call must not leave its key half-open forever
+		defer m.breaker.release(hash)
+	}
+	data, err := fn()
+
+	if m.breaker != nil {
+		m.breaker.record(hash, err)
+	}
+	if err == nil && (shouldCache == nil || shouldCache(data)) {
+		m.set(hash, data, 0)
+	}
+
+	return data, err
+}
+
+// SrcOption configures code generation
+type SrcOption func(f *FileData)
+
+// WithSourceImportPath sets the import path of the source package
+// when set the source package is imported explicitly and exported types
+// of the source package used in signatures are qualified with its name
+// this is required when the generated code lives in a different package
+func WithSourceImportPath(path string) SrcOption {
+	return func(f *FileData) {
+		f.SourceImportPath = path
+	}
+}
+
+// WithUnexported generates wrappers in the source package instead of
+// packageName so that unexported functions can be memoized as well, the
+// wrappers are named after the function prefixed with memoized ex: fetch
+// is wrapped by memoizedFetch and Fetch by MemoizedFetch
+//
+// Generated helpers (hash, cache etc) are declared in the source package
+// and must not collide with its identifiers
+func WithUnexported() SrcOption {
+	return func(f *FileData) {
+		f.Unexported = true
+	}
+}
+
+// WithRuntimeBackend generates wrappers delegating to the generic Wrap runtime
+// helper instead of bespoke caching code per function which considerably
+// shrinks the generated code when there are many tagged functions
+//
+// It is used for functions with named parameters other than slices, maps,
+// functions or variadic ones returning a value and optionally an error, other functions (ex: without parameters or with
+// metrics, cacheif, typed, async or scope=context options) are generated as usual
+func WithRuntimeBackend() SrcOption {
+	return func(f *FileData) {
+		f.RuntimeBackend = true
+	}
+}
+
+// WithNolint adds a file level //nolint directive for given linters to the
+// generated code so that linting it with golangci-lint does not report issues
+// ex: unused parameters of wrappers, without linters all linters are disabled
+// using "//nolint:all" since gofmt rewrites a bare "//nolint" to "// nolint"
+//
+//	memoize.WithNolint("unparam", "revive") // "//nolint:unparam,revive"
+func WithNolint(linters ...string) SrcOption {
+	return func(f *FileData) {
+		if len(linters) == 0 {
+			linters = []string{"all"}
+		}
+		f.Nolint = "//nolint:" + strings.Join(linters, ",")
+	}
+}
+
+// WithCaseInsensitiveDirective matches the @memo directive case-insensitively
+// ex: "// @Memo" and "//@MEMO" tag the function as well, without it such
+// near misses are not tagged and reported by FileData.Validate instead
+func WithCaseInsensitiveDirective() SrcOption {
+	return func(f *FileData) {
+		f.CaseInsensitiveDirective = true
+	}
+}
+
+// WithRegistration registers the generated functions in an init function with
+// Register so that their cached results can be cleared with InvalidateFunc or
+// InvalidateAll, names are qualified with the import path of the generated package
+// ex: "example.com/app/memo.Fetch"
+//
+// Registered functions cache their results in a Memoizer dedicated to them
+// instead of the package level cache so that invalidating one of them does not
+// clear the others, functions computed once with sync.Once and functions with
+// context scope are not registered
+func WithRegistration() SrcOption {
+	return func(f *FileData) {
+		f.Registration = true
+	}
+}
+
+// WithOutputPath sets the path the generated code is written to, imports of
+// the generated code are resolved relative to it instead of the source path
+// which is required when it is written to another package than the source
+func WithOutputPath(path string) SrcOption {
+	return func(f *FileData) {
+		f.OutputPath = path
+	}
+}
+
+// WithoutHelpers omits the package level helpers (hash, cache and metrics hooks)
+// from the generated code, it is required when code is generated for multiple
+// source files of a package into the same package since every file would
+// declare them otherwise, the helpers are then generated once with Helpers
+func WithoutHelpers() SrcOption {
+	return func(f *FileData) {
+		f.OmitHelpers = true
+	}
+}
+
+// Helpers generates the package level helpers shared by the code generated
+// with WithoutHelpers for given files of a package, the helpers needed by
+// any of the files are included, outPath is the path of the generated file
+//
+//	for _, path := range sources {
+//		data, _ := memoize.Parse(path, source[path], "memo")
+//		files = append(files, data)
+//	}
+//	out, err := memoize.Helpers(memoize.PackageTemplate, "memo/memo_helpers.go", files...)
+func Helpers(tpl, outPath string, files ...*FileData) ([]byte, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no files to generate helpers for")
+	}
+	helpers := FileData{
+		PackageName:    files[0].PackageName,
+		SourcePackage:  files[0].SourcePackage,
+		Nolint:         files[0].Nolint,
+		RuntimeBackend: files[0].RuntimeBackend,
+		OnlyHelpers:    true,
+		OutputPath:     outPath,
+	}
+	for _, file := range files {
+		helpers.Functions = append(helpers.Functions, file.Functions...)
+	}
+	return render(tpl, outPath, &helpers)
+}
+
+// GenerateString returns the code generated with PackageTemplate for given
+// source as string, it is meant for asserting generated code in tests ex:
+// locking it with a golden file regenerated by a flag when it is expected
+// to change
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+//	func TestMemoGolden(t *testing.T) {
+//		source, err := os.ReadFile("service.go")
+//		require.Nil(t, err)
+//		out, err := memoize.GenerateString("service.go", source, "memo")
+//		require.Nil(t, err)
+//		if *update {
+//			require.Nil(t, os.WriteFile("testdata/service.golden", []byte(out), 0644))
+//		}
+//		golden, err := os.ReadFile("testdata/service.golden")
+//		require.Nil(t, err)
+//		require.Equal(t, string(golden), out)
+//	}
+func GenerateString(sourcePath string, source []byte, packageName string, options ...SrcOption) (string, error) {
+	out, err := Src(PackageTemplate, sourcePath, source, packageName, options...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func File(tpl, sourceFile, packageName string, options ...SrcOption) ([]byte, error) {
 	data, err := os.ReadFile(sourceFile)
 	if err != nil {
 		return nil, err
 	}
 
-	return Src(tpl, sourceFile, data, packageName)
+	return Src(tpl, sourceFile, data, packageName, options...)
+}
+
+func Src(tpl, sourcePath string, source []byte, packageName string, options ...SrcOption) ([]byte, error) {
+	fileData, err := Parse(sourcePath, source, packageName, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return render(tpl, sourcePath, fileData)
 }
 
-func Src(tpl, sourcePath string, source []byte, packageName string) ([]byte, error) {
-	var (
-		fileData FileData
-		content  bytes.Buffer
-	)
+// render executes the template with given data and formats the result
+func render(tpl, sourcePath string, fileData *FileData) ([]byte, error) {
+	var content bytes.Buffer
 
 	tmpl, err := template.New("package_template").Parse(tpl)
 	if err != nil {
 		return nil, err
 	}
 
+	err = tmpl.Execute(&content, fileData)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileData.OutputPath != "" {
+		sourcePath = fileData.OutputPath
+	}
+	out, err := imports.Process(sourcePath, content.Bytes(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(out)
+}
+
+// Parse parses the source and returns the data of all functions
+// tagged with @memo directive without generating any code
+func Parse(sourcePath string, source []byte, packageName string, options ...SrcOption) (*FileData, error) {
+	var fileData FileData
+
 	fileData.PackageName = packageName
+	for _, option := range options {
+		option(&fileData)
+	}
 
 	fset := token.NewFileSet()
 	node, err := parser.ParseFile(fset, sourcePath, source, parser.ParseComments)
@@ -100,6 +571,8 @@ func Src(tpl, sourcePath string, source []byte, packageName string) ([]byte, err
 		return nil, err
 	}
 
+	var parseErr error
+
 	for _, nn := range node.Imports {
 		var packageImport PackageImport
 		if nn.Name != nil {
@@ -113,7 +586,39 @@ func Src(tpl, sourcePath string, source []byte, packageName string) ([]byte, err
 		fileData.Imports = append(fileData.Imports, packageImport)
 	}
 
+	// identifiers of dot imports can not be told apart from those of the
+	// source package syntactically so types declared by it are looked up
+	var declared map[string]bool
+	hasDotImport := slices.ContainsFunc(fileData.Imports, PackageImport.IsDot)
+	usesDotImport := false
+	if hasDotImport {
+		declared = declaredTypes(packageFiles(fset, node, sourcePath))
+	}
+
 	fileData.SourcePackage = node.Name.Name
+	fileData.BuildConstraint = buildConstraint(node)
+
+	if fileData.TypeCheck {
+		// must run before signatures are qualified with the source package
+		if err := typeCheck(fset, node, sourcePath, fileData.CaseInsensitiveDirective); err != nil {
+			return nil, err
+		}
+	}
+
+	if fileData.Unexported {
+		// wrappers live next to the source functions
+		fileData.PackageName = fileData.SourcePackage
+		fileData.SourceImportPath = ""
+	}
+
+	if fileData.SourceImportPath != "" {
+		var packageImport PackageImport
+		if path.Base(fileData.SourceImportPath) != fileData.SourcePackage {
+			packageImport.Name = fileData.SourcePackage
+		}
+		packageImport.Path = strconv.Quote(fileData.SourceImportPath)
+		fileData.Imports = append(fileData.Imports, packageImport)
+	}
 
 	ast.Inspect(node, func(n ast.Node) bool {
 		switch nn := n.(type) {
@@ -121,41 +626,68 @@ func Src(tpl, sourcePath string, source []byte, packageName string) ([]byte, err
 			if nn.Doc == nil {
 				return false
 			}
+			if !nn.Name.IsExported() && !fileData.Unexported {
+				// unexported functions can not be called from another package
+				return false
+			}
+
+			if fileData.SourceImportPath != "" {
+				qualifyFields(nn.Type.Params, fileData.SourcePackage, declared)
+				qualifyFields(nn.Type.Results, fileData.SourcePackage, declared)
+			}
 
 			var funcDeclaration FunctionDeclaration
 			funcDeclaration.IsExported = nn.Name.IsExported()
 			funcDeclaration.Name = nn.Name.Name
 			funcDeclaration.SourcePackage = fileData.SourcePackage
-			var funcSign strings.Builder
-			_ = printer.Fprint(&funcSign, fset, nn.Type)
-			funcDeclaration.Signature = strings.Replace(funcSign.String(), "func", "func "+funcDeclaration.Name, 1)
+			funcDeclaration.SamePackage = fileData.Unexported
+			funcDeclaration.RuntimeBackend = fileData.RuntimeBackend
+			funcDeclaration.Registration = fileData.Registration
 
 			for _, comment := range nn.Doc.List {
-				if comment.Text == "// @memo" {
-					if nn.Type.Params != nil {
-						for idx, param := range nn.Type.Params.List {
-							var funcParam FuncValue
-							funcParam.Index = idx
-							for _, name := range param.Names {
-								funcParam.Name = name.String()
-							}
-							funcParam.Type = fmt.Sprint(param.Type)
-							funcDeclaration.Params = append(funcDeclaration.Params, funcParam)
-						}
+				if directive, ok := nearMissDirective(comment.Text, fileData.CaseInsensitiveDirective); ok {
+					fileData.warnings = append(fileData.warnings, fmt.Sprintf("%s: directive %q is not recognized, use @memo", funcDeclaration.Name, directive))
+				}
+				if options, ok := parseDirective(comment.Text, fileData.CaseInsensitiveDirective); ok {
+					funcDeclaration.Directive = comment.Text
+					if hasDotImport && !usesDotImport {
+						usesDotImport = referencesUndeclared(nn.Type, declared)
 					}
+					funcDeclaration.Options = options
+					funcDeclaration.Params = funcValues(fset, nn.Type.Params)
+					funcDeclaration.Results = funcValues(fset, nn.Type.Results)
 
-					if nn.Type.Results != nil {
-						for idx, res := range nn.Type.Results.List {
-							var result FuncValue
-							result.Index = idx
-							for _, name := range res.Names {
-								result.Name = name.String()
-							}
-							result.Type = types.ExprString(res.Type)
-							funcDeclaration.Results = append(funcDeclaration.Results, result)
+					switch scope := funcDeclaration.Scope(); scope {
+					case "":
+					case "context":
+						funcDeclaration.ContextParam = contextParam(nn.Type)
+					default:
+						parseErr = fmt.Errorf("%s: unsupported memo scope %q", funcDeclaration.Name, scope)
+						return false
+					}
+					if funcDeclaration.CacheIf() != "" && !funcDeclaration.HasReturn() {
+						parseErr = fmt.Errorf("%s: cacheif requires a function with results", funcDeclaration.Name)
+						return false
+					}
+					if funcDeclaration.WantAsync() && !funcDeclaration.HasReturn() {
+						parseErr = fmt.Errorf("%s: async requires a function with results", funcDeclaration.Name)
+						return false
+					}
+					if value := funcDeclaration.OptionValue("maxsize"); value != "" {
+						if parseErr = parseMaxSize(&funcDeclaration, value); parseErr != nil {
+							return false
+						}
+					}
+					if funcDeclaration.WantTypedCache() {
+						if parseErr = validateTypedCache(funcDeclaration); parseErr != nil {
+							return false
 						}
 					}
 
+					var funcSign strings.Builder
+					_ = printer.Fprint(&funcSign, fset, nn.Type)
+					funcDeclaration.Signature = strings.Replace(funcSign.String(), "func", "func "+funcDeclaration.WrapperName(), 1)
+
 					fileData.Functions = append(fileData.Functions, funcDeclaration)
 				}
 			}
@@ -164,18 +696,258 @@ func Src(tpl, sourcePath string, source []byte, packageName string) ([]byte, err
 			return true
 		}
 	})
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if hasDotImport && !usesDotImport {
+		// unlike unused named imports unused dot imports are not removed by imports.Process
+		fileData.Imports = slices.DeleteFunc(fileData.Imports, PackageImport.IsDot)
+	}
 
-	err = tmpl.Execute(&content, fileData)
-	if err != nil {
-		return nil, err
+	return &fileData, nil
+}
+
+// parseDirective parses a @memo directive comment and returns its options
+// ex: "// @memo metrics" and "//@memo metrics" return ["metrics"]
+func parseDirective(text string, caseInsensitive bool) ([]string, bool) {
+	fields := directiveFields(text)
+	if len(fields) == 0 {
+		return nil, false
 	}
+	if fields[0] != "@memo" && (!caseInsensitive || !strings.EqualFold(fields[0], "@memo")) {
+		return nil, false
+	}
+	return fields[1:], true
+}
 
-	out, err := imports.Process(sourcePath, content.Bytes(), nil)
-	if err != nil {
-		return nil, err
+// nearMissDirective returns the first word of the comment if it looks like
+// a mistyped @memo directive ex: "@memoize", "@memo:" or "@Memo" when the
+// directive is matched case-sensitively
+func nearMissDirective(text string, caseInsensitive bool) (string, bool) {
+	fields := directiveFields(text)
+	if len(fields) == 0 || !strings.HasPrefix(strings.ToLower(fields[0]), "@memo") {
+		return "", false
+	}
+	if _, ok := parseDirective(text, caseInsensitive); ok {
+		return "", false
 	}
+	return fields[0], true
+}
 
-	return format.Source(out)
+// directiveFields returns the words of given comment without comment markers
+func directiveFields(text string) []string {
+	if strings.HasPrefix(text, "/*") {
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	}
+	return strings.Fields(strings.TrimPrefix(text, "//"))
+}
+
+// parseMaxSize parses and validates the maxsize option of the function
+func parseMaxSize(f *FunctionDeclaration, value string) error {
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return fmt.Errorf("%s: maxsize must be a positive integer, got %q", f.Name, value)
+	}
+	if f.WantContextScope() {
+		return fmt.Errorf("%s: maxsize can not be used with scope=context", f.Name)
+	}
+	if f.HasOption("typed") {
+		return fmt.Errorf("%s: maxsize can not be used with typed", f.Name)
+	}
+	f.MaxSize = size
+	return nil
+}
+
+// validateTypedCache checks if the function can be memoized with a typed
+// cache i.e it has results and all parameters can be used as map keys
+func validateTypedCache(f FunctionDeclaration) error {
+	if !f.HasReturn() {
+		return fmt.Errorf("%s: typed requires a function with results", f.Name)
+	}
+	if f.WantContextScope() {
+		return fmt.Errorf("%s: typed can not be used with scope=context", f.Name)
+	}
+	for _, param := range f.Params {
+		if param.Name == "" || param.Name == "_" {
+			return fmt.Errorf("%s: typed requires named parameters", f.Name)
+		}
+		if stringsutil.HasPrefixAny(param.Type, "[]", "...", "map[", "func(") {
+			return fmt.Errorf("%s: parameter %s of type %s can not be used as cache key", f.Name, param.Name, param.Type)
+		}
+	}
+	return nil
+}
+
+// contextParam returns the name of the context parameter of given function type
+// if the first parameter is not a named context.Context, a "ctx context.Context"
+// parameter is prepended to the parameters of the function type
+func contextParam(funcType *ast.FuncType) string {
+	params := funcType.Params
+	if len(params.List) > 0 {
+		first := params.List[0]
+		if types.ExprString(first.Type) == "context.Context" && len(first.Names) > 0 && first.Names[0].Name != "_" {
+			return first.Names[0].Name
+		}
+	}
+	ctx := &ast.Field{
+		Names: []*ast.Ident{ast.NewIdent("ctx")},
+		Type:  &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("Context")},
+	}
+	params.List = append([]*ast.Field{ctx}, params.List...)
+	return "ctx"
+}
+
+// buildConstraint returns the build constraint of the file as a //go:build line
+// legacy // +build lines are converted, empty string is returned if there is none
+func buildConstraint(node *ast.File) string {
+	var plusBuild []constraint.Expr
+	for _, group := range node.Comments {
+		if group.Pos() >= node.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) && !constraint.IsPlusBuild(comment.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(comment.Text)
+			if err != nil {
+				continue
+			}
+			if constraint.IsGoBuild(comment.Text) {
+				// //go:build takes precedence over // +build lines
+				return "//go:build " + expr.String()
+			}
+			plusBuild = append(plusBuild, expr)
+		}
+	}
+	if len(plusBuild) == 0 {
+		return ""
+	}
+	// multiple // +build lines are AND'ed together
+	expr := plusBuild[0]
+	for _, e := range plusBuild[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: e}
+	}
+	return "//go:build " + expr.String()
+}
+
+// qualifyFields qualifies exported identifiers in types of given fields with pkg
+func qualifyFields(fields *ast.FieldList, pkg string, declared map[string]bool) {
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		field.Type = qualify(field.Type, pkg, declared)
+	}
+}
+
+// qualify qualifies exported identifiers in given type expression with pkg
+// ex: *Config => *pkg.Config, already qualified identifiers are left as is
+// a non nil declared restricts it to identifiers declared by the source
+// package so that identifiers of dot imports are left as is as well
+func qualify(expr ast.Expr, pkg string, declared map[string]bool) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if t.IsExported() && (declared == nil || declared[t.Name]) {
+			return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: t}
+		}
+	case *ast.StarExpr:
+		t.X = qualify(t.X, pkg, declared)
+	case *ast.ParenExpr:
+		t.X = qualify(t.X, pkg, declared)
+	case *ast.Ellipsis:
+		t.Elt = qualify(t.Elt, pkg, declared)
+	case *ast.ArrayType:
+		if t.Len != nil {
+			t.Len = qualify(t.Len, pkg, declared)
+		}
+		t.Elt = qualify(t.Elt, pkg, declared)
+	case *ast.MapType:
+		t.Key = qualify(t.Key, pkg, declared)
+		t.Value = qualify(t.Value, pkg, declared)
+	case *ast.ChanType:
+		t.Value = qualify(t.Value, pkg, declared)
+	case *ast.FuncType:
+		qualifyFields(t.Params, pkg, declared)
+		qualifyFields(t.Results, pkg, declared)
+	case *ast.StructType:
+		qualifyFields(t.Fields, pkg, declared)
+	case *ast.InterfaceType:
+		qualifyFields(t.Methods, pkg, declared)
+	case *ast.IndexExpr:
+		t.X = qualify(t.X, pkg, declared)
+		t.Index = qualify(t.Index, pkg, declared)
+	case *ast.IndexListExpr:
+		t.X = qualify(t.X, pkg, declared)
+		for i := range t.Indices {
+			t.Indices[i] = qualify(t.Indices[i], pkg, declared)
+		}
+	}
+	return expr
+}
+
+// declaredTypes returns the names of types declared at top level by given files
+func declaredTypes(files []*ast.File) map[string]bool {
+	declared := make(map[string]bool)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				declared[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+	return declared
+}
+
+// referencesUndeclared checks if given type expression references an unqualified
+// exported identifier not in declared i.e an identifier of a dot import
+func referencesUndeclared(expr ast.Expr, declared map[string]bool) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch nn := n.(type) {
+		case *ast.SelectorExpr:
+			// qualified identifier
+			return false
+		case *ast.Field:
+			// skip names of params, results and struct fields
+			found = found || referencesUndeclared(nn.Type, declared)
+			return false
+		case *ast.Ident:
+			if nn.IsExported() && !declared[nn.Name] {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// funcValues flattens given field list into values
+// grouped names like (a, b int) produce one value per name
+// types are rendered with go/printer so that composite types like
+// struct{ X int `json:"x"` } or interface{ Foo() } are kept verbatim
+func funcValues(fset *token.FileSet, fields *ast.FieldList) []FuncValue {
+	if fields == nil {
+		return nil
+	}
+	var values []FuncValue
+	for _, field := range fields.List {
+		var typ strings.Builder
+		_ = printer.Fprint(&typ, fset, field.Type)
+		fieldType := typ.String()
+		if len(field.Names) == 0 {
+			values = append(values, FuncValue{Index: len(values), Type: fieldType})
+			continue
+		}
+		for _, name := range field.Names {
+			values = append(values, FuncValue{Index: len(values), Name: name.String(), Type: fieldType})
+		}
+	}
+	return values
 }
 
 type PackageImport struct {
@@ -183,12 +955,19 @@ type PackageImport struct {
 	Path string
 }
 
+// IsDot returns true for dot imports ex: import . "strings"
+func (p PackageImport) IsDot() bool {
+	return p.Name == "."
+}
+
 type FuncValue struct {
 	Index int
 	Name  string
 	Type  string
 }
 
+// ResultName returns the generated identifier for a result value; it is
+// derived from the position so blank (_) or unnamed results are never used
 func (f FuncValue) ResultName() string {
 	return fmt.Sprintf("result%d", f.Index)
 }
@@ -197,9 +976,110 @@ type FunctionDeclaration struct {
 	SourcePackage string
 	IsExported    bool
 	Name          string
+	Directive     string
+	Options       []string
 	Params        []FuncValue
 	Results       []FuncValue
 	Signature     string
+	// ContextParam is the name of the context parameter of the generated
+	// function for functions with context scope
+	ContextParam string
+	// SamePackage is true if the wrapper is generated in the source package
+	SamePackage bool
+	// MaxSize is the number of entries held by the cache dedicated to the
+	// function, zero means the package level cache is used
+	MaxSize int
+	// RuntimeBackend is true if the wrapper should delegate to Wrap if possible
+	RuntimeBackend bool
+	// Registration is true if the function is registered to be invalidated
+	Registration bool
+}
+
+// WrapperName returns the name of the generated function
+// wrappers generated in the source package are prefixed with memoized
+func (f FunctionDeclaration) WrapperName() string {
+	if !f.SamePackage {
+		return f.Name
+	}
+	if f.IsExported {
+		return "Memoized" + f.Name
+	}
+	return "memoized" + strings.ToUpper(f.Name[:1]) + f.Name[1:]
+}
+
+// SourceFunc returns the expression referring to the source function
+func (f FunctionDeclaration) SourceFunc() string {
+	if f.SamePackage {
+		return f.Name
+	}
+	return f.SourcePackage + "." + f.Name
+}
+
+// Hash returns a stable hash of the function signature and directive
+func (f FunctionDeclaration) Hash() string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s", f.SourcePackage, f.Name, f.Signature, f.Directive)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HasOption returns true if the directive of the function contains given option
+func (f FunctionDeclaration) HasOption(option string) bool {
+	return slices.Contains(f.Options, option)
+}
+
+// WantMetrics returns true if the function should invoke metrics hooks
+func (f FunctionDeclaration) WantMetrics() bool {
+	return f.HasOption("metrics")
+}
+
+// WantAsync returns true if an async variant of the wrapper should be generated
+// ex: "// @memo async" generates FetchAsync returning a channel receiving the
+// results of Fetch computed in a goroutine which allows callers to prefetch them
+func (f FunctionDeclaration) WantAsync() bool {
+	return f.HasOption("async")
+}
+
+// AsyncSignature returns the signature of the async variant of the wrapper
+func (f FunctionDeclaration) AsyncSignature() string {
+	var params []string
+	for _, param := range f.Params {
+		params = append(params, param.Name+" "+param.Type)
+	}
+	return fmt.Sprintf("func %sAsync(%s) <-chan %s", f.WrapperName(), strings.Join(params, ", "), f.ResultStructType())
+}
+
+// OptionValue returns the value of given key=value option of the directive
+// or empty string if the option is not set
+func (f FunctionDeclaration) OptionValue(key string) string {
+	for _, option := range f.Options {
+		if value, ok := strings.CutPrefix(option, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// Scope returns the value of the scope option of the directive
+// ex: "// @memo scope=context" returns "context", empty string means global
+func (f FunctionDeclaration) Scope() string {
+	return f.OptionValue("scope")
+}
+
+// CacheIf returns the predicate deciding whether results are cached
+// ex: "// @memo cacheif=NotEmpty" returns "pkg.NotEmpty" where pkg is the
+// source package, already qualified predicates are returned as is
+func (f FunctionDeclaration) CacheIf() string {
+	predicate := f.OptionValue("cacheif")
+	if predicate == "" || strings.Contains(predicate, ".") || f.SamePackage {
+		return predicate
+	}
+	return f.SourcePackage + "." + predicate
+}
+
+// WantContextScope returns true if results should be cached in the
+// Memoizer attached to the context instead of the package level cache
+func (f FunctionDeclaration) WantContextScope() bool {
+	return f.Scope() == "context"
 }
 
 func (f FunctionDeclaration) HasParams() bool {
@@ -213,6 +1093,24 @@ func (f FunctionDeclaration) SignatureWithPrefix(prefix string) string {
 func (f FunctionDeclaration) ParamsNames() string {
 	var params []string
 	for _, param := range f.Params {
+		if f.WantContextScope() && param.Name == f.ContextParam {
+			// context is only used to look up the cache
+			continue
+		}
+		params = append(params, param.Name)
+	}
+	return strings.Join(params, ",")
+}
+
+// CallArgs returns the arguments to call the source function with
+// variadic parameters are expanded
+func (f FunctionDeclaration) CallArgs() string {
+	var params []string
+	for _, param := range f.Params {
+		if strings.HasPrefix(param.Type, "...") {
+			params = append(params, param.Name+"...")
+			continue
+		}
 		params = append(params, param.Name)
 	}
 	return strings.Join(params, ",")
@@ -222,8 +1120,152 @@ func (f FunctionDeclaration) HasReturn() bool {
 	return len(f.Results) > 0
 }
 
+// HasErrorResult returns true if the last result is of type error
+func (f FunctionDeclaration) HasErrorResult() bool {
+	return len(f.Results) > 0 && f.Results[len(f.Results)-1].Type == "error"
+}
+
+// ErrorResultName returns the result struct field holding the error
+func (f FunctionDeclaration) ErrorResultName() string {
+	if f.HasErrorResult() {
+		return f.Results[len(f.Results)-1].ResultName()
+	}
+	panic("invalid signature type")
+}
+
+// WantSyncOnce returns true if the function can be memoized with sync.Once
+// functions returning an error use the cache so that failures are not memoized
+// and functions with context scope use the cache attached to the context
+// and functions with cacheif predicate use the cache to recompute skipped results
+// and functions with maxsize use a cache of that size
 func (f FunctionDeclaration) WantSyncOnce() bool {
-	return !f.HasParams()
+	return !f.HasParams() && !f.HasErrorResult() && !f.WantContextScope() && f.CacheIf() == "" && f.MaxSize == 0
+}
+
+// CacheVarName returns the name of the Memoizer caching results of the function
+// functions with maxsize ex: "// @memo maxsize=100" use a dedicated Memoizer
+// holding at most that many entries instead of the package level cache
+func (f FunctionDeclaration) CacheVarName() string {
+	if f.WantDedicatedCache() {
+		return fmt.Sprintf("cache%s", f.Name)
+	}
+	return "cache"
+}
+
+// WantDedicatedCache returns true if results are cached in a Memoizer dedicated
+// to the function, it is the case for functions with maxsize and for registered
+// functions so that invalidating them does not clear results of other functions
+func (f FunctionDeclaration) WantDedicatedCache() bool {
+	if f.WantTypedCache() || !f.Invalidatable() {
+		return false
+	}
+	return f.MaxSize > 0 || f.Registration
+}
+
+// CacheSize returns the max size of the Memoizer dedicated to the function
+func (f FunctionDeclaration) CacheSize() int {
+	if f.MaxSize > 0 {
+		return f.MaxSize
+	}
+	return 1000
+}
+
+// WantTypedCache returns true if results should be cached in a map dedicated to
+// the function keyed on its arguments instead of the shared interface{} cache
+// ex: "// @memo typed", the map is not bounded in size and concurrent misses
+// for the same arguments are deduplicated with a singleflight keyed on them
+func (f FunctionDeclaration) WantTypedCache() bool {
+	return f.HasOption("typed") && !f.WantSyncOnce()
+}
+
+// WantRuntimeBackend returns true if the wrapper delegates to the generic Wrap
+// runtime helper, see WithRuntimeBackend for the supported functions
+func (f FunctionDeclaration) WantRuntimeBackend() bool {
+	if !f.RuntimeBackend || !f.HasParams() || f.WantContextScope() || f.CacheIf() != "" || f.WantMetrics() || f.HasOption("typed") || f.WantAsync() {
+		return false
+	}
+	switch {
+	case len(f.Results) == 1 && !f.HasErrorResult():
+	case len(f.Results) == 2 && f.HasErrorResult():
+	default:
+		return false
+	}
+	for _, param := range f.Params {
+		if param.Name == "" || param.Name == "_" || stringsutil.HasPrefixAny(param.Type, "[]", "...", "map[", "func(") {
+			return false
+		}
+	}
+	return true
+}
+
+// WantArgsStruct returns true if arguments are grouped in a struct
+// to be used as the key of the runtime backend
+func (f FunctionDeclaration) WantArgsStruct() bool {
+	return len(f.Params) > 1
+}
+
+// WantRuntimeClosure returns true if the source function has to be adapted
+// to the func(K) (V, error) signature expected by Wrap
+func (f FunctionDeclaration) WantRuntimeClosure() bool {
+	return f.WantArgsStruct() || !f.HasErrorResult()
+}
+
+// Invalidatable returns true if cached results of the function can be cleared
+// i.e they are neither computed once with sync.Once nor cached in a context
+func (f FunctionDeclaration) Invalidatable() bool {
+	return !f.WantSyncOnce() && !f.WantContextScope()
+}
+
+// WrapVarName returns the name of the function returned by Wrap
+func (f FunctionDeclaration) WrapVarName() string {
+	return fmt.Sprintf("wrap%s", f.Name)
+}
+
+// RuntimeKeyType returns the type of the key passed to the wrapped function
+func (f FunctionDeclaration) RuntimeKeyType() string {
+	if f.WantArgsStruct() {
+		return f.ArgsStructType()
+	}
+	return f.Params[0].Type
+}
+
+// RuntimeKey returns the key passed to the wrapped function
+func (f FunctionDeclaration) RuntimeKey() string {
+	if f.WantArgsStruct() {
+		return fmt.Sprintf("%s{%s}", f.ArgsStructType(), f.ArgsStructFields())
+	}
+	return f.Params[0].Name
+}
+
+// RuntimeCallArgs returns the arguments to call the source function with from the key
+func (f FunctionDeclaration) RuntimeCallArgs() string {
+	if !f.WantArgsStruct() {
+		return "k"
+	}
+	var args []string
+	for _, param := range f.Params {
+		args = append(args, "k."+param.Name)
+	}
+	return strings.Join(args, ",")
+}
+
+// ArgsStructType returns the type of the key of the typed cache and runtime backend
+func (f FunctionDeclaration) ArgsStructType() string {
+	return fmt.Sprintf("args%s", f.Name)
+}
+
+// ArgsStructFields returns the keyed fields to build the key of the typed cache
+func (f FunctionDeclaration) ArgsStructFields() string {
+	var fields []string
+	for _, param := range f.Params {
+		fields = append(fields, fmt.Sprintf("%s: %s", param.Name, param.Name))
+	}
+	return strings.Join(fields, ",")
+}
+
+// TypedCacheVarName returns the name of the typed cache variable
+func (f FunctionDeclaration) TypedCacheVarName() string {
+	return fmt.Sprintf("cache%s", f.Name)
 }
 
 func (f FunctionDeclaration) SyncOnceVarName() string {
@@ -258,6 +1300,18 @@ func (f FunctionDeclaration) ResultFields() string {
 	return strings.Join(results, ",")
 }
 
+// ResultTypes returns the result types of the function as in its signature
+func (f FunctionDeclaration) ResultTypes() string {
+	var types []string
+	for _, result := range f.Results {
+		types = append(types, result.Type)
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
 func (f FunctionDeclaration) ResultFirstFieldType() string {
 	if len(f.Results) > 0 {
 		fieldType := f.Results[0].Type
@@ -284,9 +1338,130 @@ func (f FunctionDeclaration) ResultFirstFieldDefaultValue() string {
 	panic("invalid signature type")
 }
 
+// Warnings returns issues of the function that do not prevent generation
+// but likely cause bugs, ex: memoized channels and funcs are shared by
+// all callers so values sent or state captured leak between them
+func (f FunctionDeclaration) Warnings() []string {
+	var warnings []string
+	for _, result := range f.Results {
+		if isSharedType(result.Type) {
+			warnings = append(warnings, fmt.Sprintf("result %d of type %s is shared by all callers", result.Index, result.Type))
+		}
+	}
+	return warnings
+}
+
+// isSharedType checks if given type is a channel or func type
+func isSharedType(typ string) bool {
+	return stringsutil.HasPrefixAny(typ, "chan ", "chan<-", "<-chan", "func(")
+}
+
 type FileData struct {
-	PackageName   string
-	SourcePackage string
-	Imports       []PackageImport
-	Functions     []FunctionDeclaration
+	PackageName      string
+	SourcePackage    string
+	SourceImportPath string
+	BuildConstraint  string
+	Nolint           string
+	Unexported       bool
+	RuntimeBackend   bool
+	Imports          []PackageImport
+	Functions        []FunctionDeclaration
+
+	// CaseInsensitiveDirective matches the @memo directive case-insensitively
+	CaseInsensitiveDirective bool
+	// TypeCheck type-checks the source package before generation
+	TypeCheck bool
+	// Registration registers generated functions in the invalidation registry
+	Registration bool
+	// OmitHelpers omits the package level helpers (hash, cache and metrics hooks)
+	OmitHelpers bool
+	// OnlyHelpers generates the package level helpers without any wrapper
+	OnlyHelpers bool
+	// OutputPath is the path of the generated file used to resolve its imports
+	OutputPath string
+	// warnings are the issues found while parsing ex: mistyped directives
+	warnings []string
+}
+
+// WantMetrics returns true if any function should invoke metrics hooks
+func (f FileData) WantMetrics() bool {
+	for _, function := range f.Functions {
+		if function.WantMetrics() {
+			return true
+		}
+	}
+	return false
+}
+
+// WantTypedCache returns true if any function caches its results in a typed map
+func (f FileData) WantTypedCache() bool {
+	return !f.OnlyHelpers && slices.ContainsFunc(f.Functions, FunctionDeclaration.WantTypedCache)
+}
+
+// WantHash returns true if any function uses the hash helper to derive its key
+func (f FileData) WantHash() bool {
+	if !f.RuntimeBackend {
+		return true
+	}
+	for _, function := range f.Functions {
+		if !function.WantRuntimeBackend() {
+			return true
+		}
+	}
+	return false
+}
+
+// WantRegistration returns true if any function is registered to be invalidated
+func (f FileData) WantRegistration() bool {
+	return f.Registration && !f.OnlyHelpers && slices.ContainsFunc(f.Functions, FunctionDeclaration.Invalidatable)
+}
+
+// Validate returns the warnings of all tagged functions prefixed with their name
+// and of functions with a mistyped directive ex: "// @memoize" which are not tagged
+func (f FileData) Validate() []string {
+	warnings := slices.Clone(f.warnings)
+	for _, function := range f.Functions {
+		for _, warning := range function.Warnings() {
+			warnings = append(warnings, function.Name+": "+warning)
+		}
+	}
+	return warnings
+}
+
+// Hash returns a stable hash over all tagged functions of the file, its
+// imports and the generator template, it can be used to skip regeneration
+// when the hash is unchanged
+func (f FileData) Hash() string {
+	h := sha256.New()
+	// template changes must regenerate all files
+	_, _ = fmt.Fprintf(h, "%s\x00", PackageTemplate)
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s", f.PackageName, f.SourcePackage, f.SourceImportPath, f.BuildConstraint)
+	// imports are sorted as their order does not affect the generated code
+	imports := make([]string, 0, len(f.Imports))
+	for _, imp := range f.Imports {
+		imports = append(imports, imp.Name+" "+imp.Path)
+	}
+	slices.Sort(imports)
+	for _, imp := range imports {
+		_, _ = fmt.Fprintf(h, "\x00import %s", imp)
+	}
+	if f.RuntimeBackend {
+		_, _ = fmt.Fprint(h, "\x00runtime")
+	}
+	if f.Nolint != "" {
+		_, _ = fmt.Fprintf(h, "\x00%s", f.Nolint)
+	}
+	if f.Registration {
+		_, _ = fmt.Fprint(h, "\x00registration")
+	}
+	if f.OmitHelpers {
+		_, _ = fmt.Fprint(h, "\x00omithelpers")
+	}
+	if f.OnlyHelpers {
+		_, _ = fmt.Fprint(h, "\x00onlyhelpers")
+	}
+	for _, function := range f.Functions {
+		_, _ = fmt.Fprintf(h, "\x00%s", function.Hash())
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
@@ -1,10 +1,31 @@
 package memoize
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cespare/xxhash"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/imports"
 )
 
 func TestMemo(t *testing.T) {
@@ -21,8 +42,1349 @@ func TestMemo(t *testing.T) {
 	require.True(t, time.Since(start) < time.Duration(15*time.Second))
 }
 
+func TestNewWithoutOptions(t *testing.T) {
+	m, err := New()
+	require.Nil(t, err)
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	m.Pin("pinned")
+	value, _, cached := m.Do("pinned", fn)
+	require.False(t, cached)
+	require.Equal(t, 1, value)
+	value, _, cached = m.Do("pinned", fn)
+	require.True(t, cached)
+	require.Equal(t, 1, value)
+
+	value, _, cached = m.Do("key", fn)
+	require.False(t, cached)
+	require.Equal(t, 2, value)
+	m.Set("set", "a")
+	value, _, cached = m.Do("set", fn)
+	require.True(t, cached)
+	require.Equal(t, "a", value)
+	m.Purge()
+	_, _, cached = m.Do("key", fn)
+	require.False(t, cached)
+}
+
 func TestSrc(t *testing.T) {
 	out, err := File(PackageTemplate, "tests/test.go", "test")
 	require.Nil(t, err)
 	require.True(t, len(out) > 0)
 }
+
+func TestPin(t *testing.T) {
+	m, err := New(WithMaxSize(2))
+	require.Nil(t, err)
+
+	calls := 0
+	licenseCheck := func() (interface{}, error) {
+		calls++
+		return "valid", nil
+	}
+
+	m.Pin("license")
+	_, _, _ = m.Do("license", licenseCheck)
+
+	// fill the cache beyond its max size
+	for i := 0; i < 10; i++ {
+		_, _, _ = m.Do(fmt.Sprintf("key-%d", i), func() (interface{}, error) {
+			return i, nil
+		})
+	}
+
+	value, err, cached := m.Do("license", licenseCheck)
+	require.Nil(t, err)
+	require.True(t, cached)
+	require.Equal(t, "valid", value)
+	require.Equal(t, 1, calls)
+
+	// unpinned keys are moved back into the cache and can be evicted
+	m.Unpin("license")
+	m.Unpin("license")
+	_, _, cached = m.Do("license", licenseCheck)
+	require.True(t, cached)
+	for i := 0; i < 10; i++ {
+		m.Set(fmt.Sprintf("key-%d", i), i)
+	}
+	_, _, cached = m.Do("license", licenseCheck)
+	require.False(t, cached)
+	require.Equal(t, 2, calls)
+}
+
+func TestSetConcurrent(t *testing.T) {
+	m, err := New(WithMaxSize(100))
+	require.Nil(t, err)
+	m.Pin("pinned")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Set(strconv.Itoa(j%20), j)
+				m.Set("pinned", j)
+				if j%10 == 0 {
+					m.Pin(strconv.Itoa(j % 20))
+					m.Unpin(strconv.Itoa(j % 20))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, _, cached := m.Do("pinned", func() (interface{}, error) { return nil, nil })
+	require.True(t, cached)
+	require.Equal(t, 99, value)
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestSrcGolden(t *testing.T) {
+	tests := []string{
+		"tests/multiple_returns.go",
+		"tests/blank_results.go",
+		"tests/context_scope.go",
+		"tests/cache_if.go",
+		"tests/composite_types.go",
+		"tests/shared_results.go",
+		"tests/typed_cache.go",
+		"tests/max_size.go",
+		"tests/single_arg.go",
+		"tests/no_args.go",
+	}
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			requireGolden(t, source)
+		})
+	}
+}
+
+// requireGolden checks the code generated for source against its golden file
+func requireGolden(t *testing.T, source string, options ...SrcOption) []byte {
+	out, err := File(PackageTemplate, source, "test", options...)
+	require.Nil(t, err)
+	goldenPath := strings.TrimSuffix(source, ".go") + ".golden"
+	if *update {
+		require.Nil(t, os.WriteFile(goldenPath, out, 0644))
+	}
+	golden, err := os.ReadFile(goldenPath)
+	require.Nil(t, err)
+	require.Equal(t, string(golden), string(out))
+	return out
+}
+
+func TestGenerateString(t *testing.T) {
+	source, err := os.ReadFile("tests/single_arg.go")
+	require.Nil(t, err)
+	out, err := GenerateString("tests/single_arg.go", source, "test")
+	require.Nil(t, err)
+	golden, err := os.ReadFile("tests/single_arg.golden")
+	require.Nil(t, err)
+	require.Equal(t, string(golden), out)
+
+	_, err = GenerateString("tests/invalid.go", []byte("package"), "test")
+	require.NotNil(t, err)
+}
+
+func TestSrcNamedImports(t *testing.T) {
+	out := requireGolden(t, "tests/named_imports.go", WithSourceImportPath("github.com/projectdiscovery/utils/memoize/tests"))
+	require.Contains(t, string(out), `stdtime "time"`)
+	require.Contains(t, string(out), `. "net/url"`)
+	// types of dot imports are not qualified with the source package
+	require.Contains(t, string(out), "func TestWithDotImportAndSourceTypes(c tests.Config, u *URL) (*tests.Config, error)")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "named_imports_memo.go", out, 0)
+	require.Nil(t, err)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("test", fset, []*ast.File{file}, nil)
+	require.Nil(t, err, "generated code must compile")
+
+	// unused dot imports are dropped
+	out, err = Src(PackageTemplate, "tests/dot.go", []byte(`package tests
+
+import . "strings"
+
+// @memo
+func TestDotBody(raw string) string {
+	return ToUpper(raw)
+}
+`), "test")
+	require.Nil(t, err)
+	require.NotContains(t, string(out), `"strings"`)
+}
+
+func TestSrcAsync(t *testing.T) {
+	out := requireGolden(t, "tests/async.go")
+	require.Contains(t, string(out), "func TestPrefetchAsync(a string) <-chan resultTestPrefetch {")
+	require.Contains(t, string(out), "func TestPrefetchContextAsync(ctx context.Context, a string, b ...int) <-chan resultTestPrefetchContext {")
+	require.Contains(t, string(out), "func (r resultTestPrefetch) Values() (string, error) {")
+
+	_, err := Src(PackageTemplate, "test.go", []byte(`package tests
+
+// @memo async
+func Test(a string) {}
+`), "test")
+	require.ErrorContains(t, err, "async requires a function with results")
+
+	// the async variant populates the cache read by the sync wrapper
+	output := runGenerated(t, "tests/async.go", `
+	async, _ := (<-TestPrefetchAsync("a")).Values()
+	sync, _ := TestPrefetch("a")
+	fmt.Print(async, sync, tests.PrefetchCalls.Load())
+`)
+	require.Equal(t, "AA1", output)
+}
+
+// runGenerated generates the code for given source in a main package
+// along with a main function made of body and returns the output of running it,
+// the fmt, memoize and memoize/tests packages are imported by the main function
+// and other packages of the standard library it uses are imported automatically
+func runGenerated(t *testing.T, source, body string, options ...SrcOption) string {
+	if testing.Short() {
+		t.Skip("skipping running generated code in short mode")
+	}
+	dir, err := os.MkdirTemp("tests", "run")
+	require.Nil(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	out, err := File(PackageTemplate, source, "main", options...)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "memo.go"), out, 0644))
+	main, err := imports.Process("main.go", []byte(`package main
+
+import (
+	"fmt"
+
+	"github.com/projectdiscovery/utils/memoize"
+	"github.com/projectdiscovery/utils/memoize/tests"
+)
+
+var _, _ = memoize.Do, tests.Test
+
+func main() {`+body+`}
+`), nil)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "main.go"), main, 0644))
+	output, err := exec.Command("go", "run", "./"+filepath.ToSlash(dir)).CombinedOutput()
+	require.Nil(t, err, string(output))
+	return string(output)
+}
+
+func TestSrcRegistration(t *testing.T) {
+	out := requireGolden(t, "tests/registration.go", WithRegistration())
+	require.Contains(t, string(out), `memoize.Register(pkg+".TestRegistered", func() {`)
+	// registered functions do not share the package level cache
+	require.Contains(t, string(out), "cacheTestRegistered.Purge()")
+	require.Contains(t, string(out), "cacheTestRegisteredOther.Purge()")
+	require.Contains(t, string(out), "cacheTestRegisteredMaxSize.Purge()")
+	require.Contains(t, string(out), "cacheTestRegisteredTyped = map[argsTestRegisteredTyped]*resultTestRegisteredTyped{}")
+	// results computed once or cached in a context can not be cleared
+	require.NotContains(t, string(out), `".TestRegisteredOnce"`)
+	require.NotContains(t, string(out), `".TestRegisteredContext"`)
+
+	out, err := File(PackageTemplate, "tests/registration.go", "test")
+	require.Nil(t, err)
+	require.NotContains(t, string(out), "memoize.Register")
+
+	// invalidating a function keeps cached results of the others
+	output := runGenerated(t, "tests/registration.go", `
+	_, _ = TestRegistered("a")
+	_ = TestRegisteredOther("a")
+	fmt.Println(memoize.InvalidateFunc("main.TestRegistered"))
+	_, _ = TestRegistered("a")
+	_ = TestRegisteredOther("a")
+	fmt.Print(tests.RegisteredCalls.Load(), tests.RegisteredOtherCalls.Load())
+`, WithRegistration())
+	require.Equal(t, "true\n2 1", output)
+}
+
+func TestPackagePath(t *testing.T) {
+	require.Equal(t, "github.com/projectdiscovery/utils/memoize", CallerPackagePath())
+	require.Equal(t, "example.com/app/memo", packagePath("example.com/app/memo.init.0"))
+	require.Equal(t, "gopkg.in/yaml.v3", packagePath("gopkg.in/yaml%2ev3.init.0.func1"))
+	require.Equal(t, "main", packagePath("main.init.0"))
+}
+
+func TestInvalidate(t *testing.T) {
+	m, err := New(WithMaxSize(10))
+	require.Nil(t, err)
+	m.Pin("pinned")
+	Register("test.Invalidate", m.Purge)
+	require.Contains(t, Registered(), "test.Invalidate")
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	for _, key := range []string{"key", "pinned"} {
+		_, _, _ = m.Do(key, fn)
+		_, _, cached := m.Do(key, fn)
+		require.True(t, cached)
+	}
+
+	require.True(t, InvalidateFunc("test.Invalidate"))
+	for _, key := range []string{"key", "pinned"} {
+		_, _, cached := m.Do(key, fn)
+		require.False(t, cached, "invalidated result of %s should be computed again", key)
+	}
+	require.Equal(t, 4, calls)
+
+	InvalidateAll()
+	_, _, cached := m.Do("key", fn)
+	require.False(t, cached)
+
+	require.False(t, InvalidateFunc("test.Missing"))
+}
+
+func TestSrcRuntimeBackend(t *testing.T) {
+	out := requireGolden(t, "tests/runtime_backend.go", WithRuntimeBackend())
+	require.Contains(t, string(out), "var wrapTestRuntime = memoize.Wrap(cache, tests.TestRuntime)")
+	require.Contains(t, string(out), "var wrapTestRuntimeMultipleArgs = memoize.Wrap(cacheTestRuntimeMultipleArgs, func(k argsTestRuntimeMultipleArgs) (int, error) {")
+	// functions with metrics are not supported by the runtime backend
+	require.Contains(t, string(out), `h := hash("TestRuntimeFallback", a)`)
+	// parameters do not have to be comparable
+	require.Contains(t, string(out), "var wrapTestRuntimeQuery = memoize.Wrap(cache, tests.TestRuntimeQuery)")
+
+	bespoke, err := File(PackageTemplate, "tests/runtime_backend.go", "test")
+	require.Nil(t, err)
+	require.Less(t, len(out), len(bespoke))
+
+	output := runGenerated(t, "tests/runtime_backend.go", `
+	fmt.Print(TestRuntimeQuery(tests.Query{Hosts: []string{"a", "b"}}))
+`, WithRuntimeBackend(), WithSourceImportPath("github.com/projectdiscovery/utils/memoize/tests"))
+	require.Equal(t, "2 <nil>", output)
+}
+
+func TestDoConcurrent(t *testing.T) {
+	m, err := New(WithMaxSize(5))
+	require.Nil(t, err)
+
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err, _ := m.Do("test", func() (interface{}, error) {
+				calls.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return "b", nil
+			})
+			require.Nil(t, err)
+			require.Equal(t, "b", value)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestSet(t *testing.T) {
+	m, err := New(WithMaxSize(5))
+	require.Nil(t, err)
+
+	m.Set("seeded", "a")
+	m.SetWithTTL("seeded-ttl", "b", time.Minute)
+
+	notCalled := func() (interface{}, error) {
+		t.Fatal("expected seeded value to be returned")
+		return nil, nil
+	}
+
+	value, err, cached := m.Do("seeded", notCalled)
+	require.Nil(t, err)
+	require.True(t, cached)
+	require.Equal(t, "a", value)
+
+	value, err, cached = m.Do("seeded-ttl", notCalled)
+	require.Nil(t, err)
+	require.True(t, cached)
+	require.Equal(t, "b", value)
+
+	m.SetWithTTL("expired", "c", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	value, _, cached = m.Do("expired", func() (interface{}, error) {
+		return "d", nil
+	})
+	require.False(t, cached)
+	require.Equal(t, "d", value)
+}
+
+func TestValueCloner(t *testing.T) {
+	m, err := New(WithMaxSize(10), WithValueCloner(func(v interface{}) interface{} {
+		return slices.Clone(v.([]string))
+	}))
+	require.Nil(t, err)
+
+	fn := func() (interface{}, error) {
+		return []string{"a", "b"}, nil
+	}
+	value, err, cached := m.Do("key", fn)
+	require.Nil(t, err)
+	require.False(t, cached)
+	value.([]string)[0] = "mutated"
+
+	value, err, cached = m.Do("key", fn)
+	require.Nil(t, err)
+	require.True(t, cached)
+	require.Equal(t, []string{"a", "b"}, value)
+	value.([]string)[1] = "mutated"
+
+	value, _, _ = m.Do("key", fn)
+	require.Equal(t, []string{"a", "b"}, value)
+
+	_, err = New(WithValueCloner(nil))
+	require.NotNil(t, err)
+}
+
+func TestWarm(t *testing.T) {
+	m, err := New(WithMaxSize(10))
+	require.Nil(t, err)
+
+	entries := make(map[string]interface{})
+	for i := 0; i < 10; i++ {
+		entries["key"+strconv.Itoa(i)] = i
+	}
+	m.Warm(entries)
+
+	notCalled := func() (interface{}, error) {
+		t.Fatal("expected warmed value to be returned")
+		return nil, nil
+	}
+	for key, want := range entries {
+		value, err, cached := m.Do(key, notCalled)
+		require.Nil(t, err)
+		require.True(t, cached)
+		require.Equal(t, want, value)
+	}
+
+	// entries beyond the max size are evicted
+	for i := 10; i < 25; i++ {
+		entries["key"+strconv.Itoa(i)] = i
+	}
+	m.Warm(entries)
+	require.LessOrEqual(t, m.cache.Len(false), 10)
+}
+
+func TestFileDataHash(t *testing.T) {
+	source := []byte(`package tests
+
+// @memo
+func Test(a string) string {
+	return a
+}
+`)
+	fileData, err := Parse("test.go", source, "test")
+	require.Nil(t, err)
+	hash := fileData.Hash()
+
+	// unrelated changes in the body do not change the hash
+	fileData, err = Parse("test.go", bytes.Replace(source, []byte("return a"), []byte("return a + a"), 1), "test")
+	require.Nil(t, err)
+	require.Equal(t, hash, fileData.Hash())
+
+	fileData, err = Parse("test.go", bytes.Replace(source, []byte("a string"), []byte("a int"), 1), "test")
+	require.Nil(t, err)
+	require.NotEqual(t, hash, fileData.Hash())
+
+	// imports are part of the hash irrespective of their order
+	imported := []byte(`package tests
+
+import (
+	"example.com/a/foo"
+	bar "example.com/b/bar"
+)
+
+// @memo
+func Test(a foo.Bar) bar.Baz {
+	return nil
+}
+`)
+	fileData, err = Parse("test.go", imported, "test")
+	require.Nil(t, err)
+	hash = fileData.Hash()
+	fileData, err = Parse("test.go", bytes.Replace(imported, []byte("example.com/a/foo"), []byte("example.com/c/foo"), 1), "test")
+	require.Nil(t, err)
+	require.NotEqual(t, hash, fileData.Hash())
+	fileData, err = Parse("test.go", bytes.Replace(imported, []byte("bar \"example.com/b/bar\""), []byte("bar \"example.com/b/bar/v2\""), 1), "test")
+	require.Nil(t, err)
+	require.NotEqual(t, hash, fileData.Hash())
+	fileData, err = Parse("test.go", bytes.Replace(imported, []byte("\t\"example.com/a/foo\"\n\tbar \"example.com/b/bar\""), []byte("\tbar \"example.com/b/bar\"\n\t\"example.com/a/foo\""), 1), "test")
+	require.Nil(t, err)
+	require.Equal(t, hash, fileData.Hash())
+
+	// template changes are part of the hash
+	defer func(template string) { PackageTemplate = template }(PackageTemplate)
+	PackageTemplate += "\n"
+	require.NotEqual(t, hash, fileData.Hash())
+}
+
+func TestSrcBuildConstraint(t *testing.T) {
+	tests := map[string]string{
+		"//go:build linux":                    "//go:build linux",
+		"// +build linux,amd64":               "//go:build linux && amd64",
+		"//go:build linux\n// +build linux":   "//go:build linux",
+		"// +build linux\n// +build !android": "//go:build linux && !android",
+	}
+	for constraint, expected := range tests {
+		source := constraint + `
+
+package tests
+
+// @memo
+func TestConstraint() string {
+	return "a"
+}
+`
+		out, err := Src(PackageTemplate, "tests/constraint.go", []byte(source), "test")
+		require.Nil(t, err)
+
+		node, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments)
+		require.Nil(t, err)
+		require.Equal(t, expected, buildConstraint(node))
+	}
+}
+
+func TestSrcNolint(t *testing.T) {
+	source := []byte(`//go:build linux
+
+package tests
+
+// @memo
+func TestNolint(a string) string {
+	return a
+}
+`)
+	out, err := Src(PackageTemplate, "tests/nolint.go", source, "test")
+	require.Nil(t, err)
+	require.NotContains(t, string(out), "//nolint")
+
+	out, err = Src(PackageTemplate, "tests/nolint.go", source, "test", WithNolint())
+	require.Nil(t, err)
+	require.Contains(t, string(out), "//go:build linux\n\n//nolint:all\npackage test\n")
+
+	out, err = Src(PackageTemplate, "tests/nolint.go", source, "test", WithNolint("unparam", "revive"))
+	require.Nil(t, err)
+	require.Contains(t, string(out), "//nolint:unparam,revive\npackage test\n")
+
+	// the directive must not turn into the package doc of the constraint
+	node, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments)
+	require.Nil(t, err)
+	require.Equal(t, "//go:build linux", buildConstraint(node))
+}
+
+func TestPeriodic(t *testing.T) {
+	var calls atomic.Int32
+	get := Periodic(100*time.Millisecond, func() (int32, error) {
+		time.Sleep(10 * time.Millisecond)
+		return calls.Add(1), nil
+	})
+
+	// concurrent calls share a single computation
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := get()
+			require.Nil(t, err)
+			require.Equal(t, int32(1), v)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), calls.Load())
+
+	v, err := get()
+	require.Nil(t, err)
+	require.Equal(t, int32(1), v)
+
+	time.Sleep(150 * time.Millisecond)
+	v, err = get()
+	require.Nil(t, err)
+	require.Equal(t, int32(2), v)
+	require.Equal(t, int32(2), calls.Load())
+
+	// errors are not cached
+	var failures atomic.Int32
+	failing := Periodic(time.Minute, func() (string, error) {
+		failures.Add(1)
+		return "", errors.New("remote unavailable")
+	})
+	_, err = failing()
+	require.NotNil(t, err)
+	_, err = failing()
+	require.NotNil(t, err)
+	require.Equal(t, int32(2), failures.Load())
+}
+
+func TestWrap(t *testing.T) {
+	m, err := New(WithMaxSize(10))
+	require.Nil(t, err)
+
+	var calls atomic.Int32
+	double := Wrap(m, func(i int) (int, error) {
+		calls.Add(1)
+		return i * 2, nil
+	})
+	square := Wrap(m, func(i int) (int, error) {
+		return i * i, nil
+	})
+
+	v, err := double(3)
+	require.Nil(t, err)
+	require.Equal(t, 6, v)
+
+	// same argument on a different function must not hit the cache of double
+	v, err = square(3)
+	require.Nil(t, err)
+	require.Equal(t, 9, v)
+
+	v, err = double(3)
+	require.Nil(t, err)
+	require.Equal(t, 6, v)
+	require.Equal(t, int32(1), calls.Load())
+
+	// arguments formatted the same by fmt must not share an entry
+	type args struct {
+		a, b string
+	}
+	join := Wrap(m, func(k args) (string, error) {
+		return k.a + "|" + k.b, nil
+	})
+	v1, err := join(args{"a b", "c"})
+	require.Nil(t, err)
+	require.Equal(t, "a b|c", v1)
+	v1, err = join(args{"a", "b c"})
+	require.Nil(t, err)
+	require.Equal(t, "a|b c", v1)
+
+	// arguments do not need to be comparable
+	var countCalls atomic.Int32
+	count := Wrap(m, func(hosts []string) (int, error) {
+		countCalls.Add(1)
+		return len(hosts), nil
+	})
+	_, _ = count([]string{"a", "b"})
+	v, err = count([]string{"a", "b"})
+	require.Nil(t, err)
+	require.Equal(t, 2, v)
+	require.Equal(t, int32(1), countCalls.Load())
+}
+
+func TestSrcNoArgs(t *testing.T) {
+	out, err := File(PackageTemplate, "tests/no_args.go", "test")
+	require.Nil(t, err)
+
+	// no-arg functions must return package level results without allocating
+	src := string(out)
+	require.Contains(t, src, "onceTestWithNoArgs.Do(")
+	require.Contains(t, src, "vresultTestWithNoArgs resultTestWithNoArgs")
+	require.NotContains(t, src, "&resultTestWithNoArgs{}")
+	require.NotContains(t, src, "cache.Do(")
+}
+
+func TestSrcMetrics(t *testing.T) {
+	source := []byte(`package tests
+
+// @memo metrics
+func TestMetrics(a string) string {
+	return a
+}
+
+// @memo metrics
+func TestMetricsNoArgs() string {
+	return "a"
+}
+
+// @memo
+func TestNoMetrics(a string) string {
+	return a
+}
+`)
+	out, err := Src(PackageTemplate, "tests/metrics.go", source, "test")
+	require.Nil(t, err)
+
+	src := string(out)
+	require.Contains(t, src, "var OnHit, OnMiss func(name string)")
+	require.Contains(t, src, `onMetrics("TestMetrics", hit)`)
+	require.Contains(t, src, `onMetrics("TestMetricsNoArgs", hit)`)
+	require.NotContains(t, src, `onMetrics("TestNoMetrics", hit)`)
+
+	out, err = File(PackageTemplate, "tests/single_arg.go", "test")
+	require.Nil(t, err)
+	require.NotContains(t, string(out), "OnHit")
+}
+
+func TestSrcWithSourceImportPath(t *testing.T) {
+	out, err := File(PackageTemplate, "tests/types.go", "test", WithSourceImportPath("github.com/projectdiscovery/utils/memoize/tests"))
+	require.Nil(t, err)
+	require.Contains(t, string(out), "func TestWithSourceTypes(c tests.Config, names ...string) (*tests.Config, map[string][]tests.Config)")
+
+	// generated code must type check against the source package
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, "memo.go", out, 0)
+	require.Nil(t, err)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("test", fset, []*ast.File{node}, nil)
+	require.Nil(t, err)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	_, err := New(WithMaxSize(5), WithCircuitBreaker(0, time.Second))
+	require.NotNil(t, err)
+
+	cooldown := 50 * time.Millisecond
+	m, err := New(WithMaxSize(5), WithCircuitBreaker(2, cooldown))
+	require.Nil(t, err)
+
+	var calls int
+	failing := func() (interface{}, error) {
+		calls++
+		return nil, fmt.Errorf("failure %d", calls)
+	}
+
+	// closed: failures below threshold invoke fn
+	_, err, cached := m.Do("key", failing)
+	require.EqualError(t, err, "failure 1")
+	require.False(t, cached)
+	_, err, _ = m.Do("key", failing)
+	require.EqualError(t, err, "failure 2")
+	require.Equal(t, 2, calls)
+
+	// open: last error is returned without invoking fn
+	_, err, cached = m.Do("key", failing)
+	require.EqualError(t, err, "failure 2")
+	require.True(t, cached)
+	require.Equal(t, 2, calls)
+
+	// other keys are not affected
+	value, err, _ := m.Do("other", func() (interface{}, error) { return "ok", nil })
+	require.Nil(t, err)
+	require.Equal(t, "ok", value)
+
+	// half-open: a failed trial re-opens the breaker
+	time.Sleep(cooldown)
+	_, err, _ = m.Do("key", failing)
+	require.EqualError(t, err, "failure 3")
+	_, err, _ = m.Do("key", failing)
+	require.EqualError(t, err, "failure 3")
+	require.Equal(t, 3, calls)
+
+	// half-open: a successful trial closes the breaker
+	time.Sleep(cooldown)
+	value, err, cached = m.Do("key", func() (interface{}, error) {
+		calls++
+		return "recovered", nil
+	})
+	require.Nil(t, err)
+	require.False(t, cached)
+	require.Equal(t, "recovered", value)
+	require.Equal(t, 4, calls)
+
+	value, err, cached = m.Do("key", failing)
+	require.Nil(t, err)
+	require.True(t, cached)
+	require.Equal(t, "recovered", value)
+
+	// recovered keys are not tracked anymore
+	require.Empty(t, m.breaker.states)
+	_, err, _ = m.Do("closed", failing)
+	require.NotNil(t, err)
+	require.Len(t, m.breaker.states, 1)
+	time.Sleep(cooldown)
+	_, err, _ = m.Do("another", failing)
+	require.NotNil(t, err)
+	require.Len(t, m.breaker.states, 1)
+	require.Contains(t, m.breaker.states, xxhash.Sum64String("another"))
+}
+
+func TestCircuitBreakerPanic(t *testing.T) {
+	cooldown := 50 * time.Millisecond
+	m, err := New(WithMaxSize(5), WithCircuitBreaker(1, cooldown))
+	require.Nil(t, err)
+
+	var calls int
+	_, err, _ = m.Do("key", func() (interface{}, error) {
+		calls++
+		return nil, errors.New("failure")
+	})
+	require.NotNil(t, err)
+
+	// a panicking trial call releases the half-open key
+	time.Sleep(cooldown)
+	require.Panics(t, func() {
+		_, _, _ = m.Do("key", func() (interface{}, error) {
+			calls++
+			panic("trial")
+		})
+	})
+	value, err, cached := m.Do("key", func() (interface{}, error) {
+		calls++
+		return "recovered", nil
+	})
+	require.Nil(t, err)
+	require.False(t, cached)
+	require.Equal(t, "recovered", value)
+	require.Equal(t, 3, calls)
+}
+
+func TestNewContext(t *testing.T) {
+	require.Nil(t, FromContext(context.Background()))
+
+	ctx1, err := NewContext(context.Background())
+	require.Nil(t, err)
+	ctx2, err := NewContext(context.Background())
+	require.Nil(t, err)
+	require.NotNil(t, FromContext(ctx1))
+	require.NotSame(t, FromContext(ctx1), FromContext(ctx2))
+
+	var calls int
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	value, _, _ := FromContext(ctx1).Do("key", fn)
+	require.Equal(t, 1, value)
+	value, _, cached := FromContext(ctx1).Do("key", fn)
+	require.True(t, cached)
+	require.Equal(t, 1, value)
+
+	// contexts do not share results
+	value, _, cached = FromContext(ctx2).Do("key", fn)
+	require.False(t, cached)
+	require.Equal(t, 2, value)
+
+	_, err = NewContext(context.Background(), WithCircuitBreaker(0, time.Second))
+	require.NotNil(t, err)
+}
+
+func TestSrcContextScope(t *testing.T) {
+	data, err := Parse("test.go", []byte(`package tests
+
+import "context"
+
+// @memo scope=context
+func Test(a string) string {
+	return a
+}
+
+// @memo scope=context
+func TestCtx(c context.Context, a string) string {
+	return a
+}
+`), "test")
+	require.Nil(t, err)
+	require.Len(t, data.Functions, 2)
+
+	require.Equal(t, "func Test(ctx context.Context, a string) string", data.Functions[0].Signature)
+	require.Equal(t, "ctx", data.Functions[0].ContextParam)
+	require.Equal(t, "a", data.Functions[0].ParamsNames())
+	require.Equal(t, "a", data.Functions[0].CallArgs())
+
+	require.Equal(t, "func TestCtx(c context.Context, a string) string", data.Functions[1].Signature)
+	require.Equal(t, "c", data.Functions[1].ContextParam)
+	require.Equal(t, "a", data.Functions[1].ParamsNames())
+	require.Equal(t, "c,a", data.Functions[1].CallArgs())
+
+	_, err = Parse("test.go", []byte(`package tests
+
+// @memo scope=request
+func Test(a string) string {
+	return a
+}
+`), "test")
+	require.ErrorContains(t, err, "unsupported memo scope")
+}
+
+func TestDoIf(t *testing.T) {
+	m, err := New(WithMaxSize(5))
+	require.Nil(t, err)
+
+	notEmpty := func(v interface{}) bool {
+		return v.(string) != ""
+	}
+
+	var calls int
+	empty := func() (interface{}, error) {
+		calls++
+		return "", nil
+	}
+	_, _, cached := m.DoIf("empty", notEmpty, empty)
+	require.False(t, cached)
+	_, _, cached = m.DoIf("empty", notEmpty, empty)
+	require.False(t, cached, "expected value failing the predicate not to be cached")
+	require.Equal(t, 2, calls)
+
+	value, _, _ := m.DoIf("non-empty", notEmpty, func() (interface{}, error) {
+		return "a", nil
+	})
+	require.Equal(t, "a", value)
+	value, _, cached = m.DoIf("non-empty", notEmpty, func() (interface{}, error) {
+		return "b", nil
+	})
+	require.True(t, cached)
+	require.Equal(t, "a", value)
+
+	_, err = Parse("test.go", []byte(`package tests
+
+// @memo cacheif=NotEmpty
+func Test(a string) {
+}
+`), "test")
+	require.ErrorContains(t, err, "cacheif requires a function with results")
+}
+
+func TestDebugCollisionCheck(t *testing.T) {
+	m, err := New(WithMaxSize(5), WithDebugCollisionCheck())
+	require.Nil(t, err)
+
+	var warnings []string
+	m.collision.warn = func(funcHash, tag, otherTag string) {
+		warnings = append(warnings, fmt.Sprintf("%s:%s:%s", funcHash, tag, otherTag))
+	}
+
+	fn := func() (interface{}, error) { return 1, nil }
+	_, _, _ = m.DoTagged("key", "lookup", fn)
+	_, _, _ = m.DoTagged("key", "lookup", fn)
+	require.Empty(t, warnings)
+
+	_, _, _ = m.DoTagged("key", "resolve", fn)
+	require.Equal(t, []string{"key:lookup:resolve"}, warnings)
+
+	// untagged calls are identified by fn
+	warnings = nil
+	for i := 0; i < 2; i++ {
+		_, _, _ = m.Do("untagged", func() (interface{}, error) { return 1, nil })
+	}
+	require.Empty(t, warnings)
+	_, _, _ = m.Do("untagged", func() (interface{}, error) { return 2, nil })
+	require.Len(t, warnings, 1)
+}
+
+func TestSrcTypeCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "types.go"), []byte(`package broken
+
+type Result struct{}
+`), 0644))
+
+	sourcePath := filepath.Join(dir, "broken.go")
+	source := []byte(`package broken
+
+import "time"
+
+// @memo
+func Fetch(a string, timeout time.Duration) (Result, error) {
+	return Result{}, nil
+}
+
+// @memo
+func Broken(a Missing) (*Undefined, error) {
+	return nil, nil
+}
+
+// not tagged
+func Other(a Unknown) {}
+`)
+
+	// without type check only the syntax is checked
+	_, err := Src(PackageTemplate, sourcePath, source, "memo")
+	require.Nil(t, err)
+
+	_, err = Src(PackageTemplate, sourcePath, source, "memo", WithTypeCheck())
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Broken: ")
+	require.Contains(t, err.Error(), "undefined: Missing")
+	require.Contains(t, err.Error(), "undefined: Undefined")
+	require.NotContains(t, err.Error(), "Fetch")
+	require.NotContains(t, err.Error(), "Unknown")
+
+	// types declared in other files of the package are resolved
+	valid := bytes.Split(source, []byte("// @memo\nfunc Broken"))[0]
+	out, err := Src(PackageTemplate, sourcePath, valid, "memo", WithTypeCheck())
+	require.Nil(t, err)
+	require.Contains(t, string(out), "func Fetch(a string, timeout time.Duration) (Result, error)")
+}
+
+func TestDirectiveVariants(t *testing.T) {
+	source := []byte(`package tests
+
+// @memo
+func Spaced(a string) string { return a }
+
+//@memo metrics
+func NoSpace(a string) string { return a }
+
+/* @memo */
+func Block(a string) string { return a }
+
+// @Memo
+func Upper(a string) string { return a }
+
+//@MEMO metrics
+func UpperNoSpace(a string) string { return a }
+
+// @memoize
+func Memoize(a string) string { return a }
+
+// memo is not a directive
+func Plain(a string) string { return a }
+`)
+	names := func(data *FileData) []string {
+		var names []string
+		for _, function := range data.Functions {
+			names = append(names, function.Name)
+		}
+		return names
+	}
+
+	data, err := Parse("test.go", source, "test")
+	require.Nil(t, err)
+	require.Equal(t, []string{"Spaced", "NoSpace", "Block"}, names(data))
+	require.Equal(t, []string{"metrics"}, data.Functions[1].Options)
+	require.Equal(t, []string{
+		`Upper: directive "@Memo" is not recognized, use @memo`,
+		`UpperNoSpace: directive "@MEMO" is not recognized, use @memo`,
+		`Memoize: directive "@memoize" is not recognized, use @memo`,
+	}, data.Validate())
+
+	data, err = Parse("test.go", source, "test", WithCaseInsensitiveDirective())
+	require.Nil(t, err)
+	require.Equal(t, []string{"Spaced", "NoSpace", "Block", "Upper", "UpperNoSpace"}, names(data))
+	require.Equal(t, []string{"metrics"}, data.Functions[4].Options)
+	require.Equal(t, []string{`Memoize: directive "@memoize" is not recognized, use @memo`}, data.Validate())
+}
+
+func TestValidate(t *testing.T) {
+	data, err := Parse("test.go", []byte(`package tests
+
+// @memo
+func TestChan(a string) chan int {
+	return nil
+}
+
+// @memo
+func TestString(a string) string {
+	return a
+}
+`), "test")
+	require.Nil(t, err)
+	require.Equal(t, []string{"TestChan: result 0 of type chan int is shared by all callers"}, data.Validate())
+	require.Empty(t, data.Functions[1].Warnings())
+
+	out, err := File(PackageTemplate, "tests/shared_results.go", "test")
+	require.Nil(t, err)
+	require.Contains(t, string(out), "// WARNING: result 0 of type <-chan string is shared by all callers\nfunc TestWithChanResult(")
+}
+
+func TestDefault(t *testing.T) {
+	require.NotNil(t, Default())
+	require.Same(t, Default(), Default())
+
+	var calls int32
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err, _ := Do("test-default", fn)
+			require.Nil(t, err)
+			require.Equal(t, "value", value)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	value, _, cached := Default().Do("test-default", fn)
+	require.True(t, cached)
+	require.Equal(t, "value", value)
+}
+
+func TestMaxBytes(t *testing.T) {
+	_, err := New(WithMaxBytes(0, nil))
+	require.NotNil(t, err)
+
+	m, err := New(WithMaxBytes(10, func(v interface{}) int64 {
+		return int64(len(v.(string)))
+	}))
+	require.Nil(t, err)
+
+	value := func(v string) func() (interface{}, error) {
+		return func() (interface{}, error) { return v, nil }
+	}
+	cached := func(key string) bool {
+		return m.cache.Has(xxhash.Sum64String(key))
+	}
+
+	_, _, _ = m.Do("a", value("aaaa"))
+	_, _, _ = m.Do("b", value("bbbb"))
+	require.Equal(t, int64(8), m.budget.usedBytes())
+
+	// a is used recently so b is evicted to fit c
+	_, _, hit := m.Do("a", value("aaaa"))
+	require.True(t, hit)
+	_, _, _ = m.Do("c", value("cccc"))
+	require.LessOrEqual(t, m.budget.usedBytes(), int64(10))
+	require.True(t, cached("a"))
+	require.True(t, cached("c"))
+	require.False(t, cached("b"))
+
+	// oversized values evict everything and are not cached
+	_, _, _ = m.Do("large", value("xxxxxxxxxxxxxxxx"))
+	require.Equal(t, int64(0), m.budget.usedBytes())
+	require.False(t, cached("large"))
+	require.False(t, cached("a"))
+}
+
+func TestExpirationJitter(t *testing.T) {
+	_, err := New(WithExpirationJitter(1))
+	require.NotNil(t, err)
+	_, err = New(WithExpirationJitter(-0.1))
+	require.NotNil(t, err)
+
+	m, err := New(WithMaxSize(10))
+	require.Nil(t, err)
+	require.Equal(t, time.Minute, m.jitterTTL(time.Minute), "no jitter by default")
+
+	m, err = New(WithMaxSize(10), WithExpirationJitter(0.2))
+	require.Nil(t, err)
+
+	base := 10 * time.Second
+	seen := make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		ttl := m.jitterTTL(base)
+		require.GreaterOrEqual(t, ttl, 8*time.Second)
+		require.LessOrEqual(t, ttl, 12*time.Second)
+		seen[ttl] = struct{}{}
+	}
+	require.Greater(t, len(seen), 1, "effective ttls must vary")
+
+	// entries still expire with jitter applied
+	m.SetWithTTL("jittered", "a", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	_, _, hit := m.Do("jittered", func() (interface{}, error) { return "b", nil })
+	require.False(t, hit)
+
+	// entries computed by Do expire with the jittered default ttl
+	_, err = New(WithTTL(-time.Second))
+	require.NotNil(t, err)
+	ttl := 100 * time.Millisecond
+	m, err = New(WithMaxSize(100), WithTTL(ttl), WithExpirationJitter(0.5))
+	require.Nil(t, err)
+	for i := 0; i < 50; i++ {
+		_, _, _ = m.Do(strconv.Itoa(i), func() (interface{}, error) { return i, nil })
+	}
+	expired := func() int {
+		var n int
+		for i := 0; i < 50; i++ {
+			if _, err := m.cache.GetIFPresent(xxhash.Sum64String(strconv.Itoa(i))); err != nil {
+				n++
+			}
+		}
+		return n
+	}
+	time.Sleep(ttl / 4)
+	require.Equal(t, 0, expired(), "entries expire no earlier than ttl*(1-jitter)")
+	time.Sleep(ttl * 3 / 4)
+	require.Greater(t, expired(), 0, "some entries expire before the base ttl")
+	require.Less(t, expired(), 50, "some entries expire after the base ttl")
+	time.Sleep(ttl * 3 / 4)
+	require.Equal(t, 50, expired(), "entries expire no later than ttl*(1+jitter)")
+}
+
+func TestSrcTypedCache(t *testing.T) {
+	data, err := Parse("test.go", []byte(`package tests
+
+// @memo typed
+func Test(a string, b int) (string, error) {
+	return a, nil
+}
+`), "test")
+	require.Nil(t, err)
+	require.True(t, data.Functions[0].WantTypedCache())
+	require.Equal(t, "argsTest", data.Functions[0].ArgsStructType())
+	require.Equal(t, "a: a,b: b", data.Functions[0].ArgsStructFields())
+
+	for name, src := range map[string]string{
+		"can not be used as cache key":     `func Test(a []string) string { return "" }`,
+		"requires named parameters":        `func Test(string) string { return "" }`,
+		"requires a function with results": `func Test(a string) {}`,
+	} {
+		_, err := Parse("test.go", []byte("package tests\n\n// @memo typed\n"+src+"\n"), "test")
+		require.ErrorContains(t, err, name)
+	}
+}
+
+func TestSrcTypedCacheSingleflight(t *testing.T) {
+	out := requireGolden(t, "tests/typed_cache.go")
+	require.Contains(t, string(out), "cacheTestTypedSlowGroup.Do(key")
+
+	// concurrent misses for the same arguments call the function once
+	output := runGenerated(t, "tests/typed_cache.go", `
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = TestTypedSlow(1)
+		}()
+	}
+	wg.Wait()
+	fmt.Print(TestTypedSlow(1), tests.TypedSlowCalls.Load())
+`)
+	require.Equal(t, "1 1", output)
+}
+
+func TestDoKey(t *testing.T) {
+	type query struct {
+		Host  string
+		Port  int
+		Flags map[string]bool
+	}
+
+	m, err := New(WithMaxSize(10))
+	require.Nil(t, err)
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	value, _, cached := m.DoKey(query{Host: "example.com", Port: 443, Flags: map[string]bool{"a": true, "b": false}}, fn)
+	require.False(t, cached)
+	require.Equal(t, 1, value)
+
+	value, _, cached = m.DoKey(query{Host: "example.com", Port: 443, Flags: map[string]bool{"b": false, "a": true}}, fn)
+	require.True(t, cached)
+	require.Equal(t, 1, value)
+
+	_, _, cached = m.DoKey(query{Host: "example.com", Port: 80}, fn)
+	require.False(t, cached)
+	require.Equal(t, 2, calls)
+
+	require.NotEqual(t, HashArgs(1), HashArgs("1"))
+	require.Equal(t, HashArgs("a", 1), HashArgs("a", 1))
+}
+
+func TestSrcMaxSize(t *testing.T) {
+	data, err := Parse("test.go", []byte(`package tests
+
+// @memo maxsize=250
+func Test(a string) string {
+	return a
+}
+`), "test")
+	require.Nil(t, err)
+	require.Equal(t, 250, data.Functions[0].MaxSize)
+	require.Equal(t, "cacheTest", data.Functions[0].CacheVarName())
+
+	out, err := Src(PackageTemplate, "test.go", []byte(`package tests
+
+// @memo maxsize=250
+func Test(a string) string {
+	return a
+}
+`), "test")
+	require.Nil(t, err)
+	require.Contains(t, string(out), "var cacheTest, _ = memoize.New(memoize.WithMaxSize(250))")
+
+	for directive, msg := range map[string]string{
+		"maxsize=0":                "maxsize must be a positive integer",
+		"maxsize=big":              "maxsize must be a positive integer",
+		"maxsize=10 scope=context": "maxsize can not be used with scope=context",
+		"maxsize=10 typed":         "maxsize can not be used with typed",
+	} {
+		_, err := Parse("test.go", []byte("package tests\n\n// @memo "+directive+"\nfunc Test(a string) string { return a }\n"), "test")
+		require.ErrorContains(t, err, msg, directive)
+	}
+}
+
+func TestHashArgsNormalization(t *testing.T) {
+	now := time.Now()
+	// Round(0) strips the monotonic clock reading
+	require.Equal(t, HashArgs(now), HashArgs(now.Round(0)))
+	require.Equal(t, HashArgs(now), HashArgs(now.UTC()))
+	require.NotEqual(t, HashArgs(now), HashArgs(now.Add(time.Nanosecond)))
+
+	type query struct {
+		Host  string
+		since time.Time
+	}
+	require.Equal(t, HashArgs(query{Host: "a", since: now}), HashArgs(query{Host: "a", since: now.Round(0)}))
+	require.NotEqual(t, HashArgs(query{Host: "a", since: now}), HashArgs(query{Host: "b", since: now}))
+
+	require.Equal(t, HashArgs(math.NaN()), HashArgs(math.NaN()))
+	require.NotEqual(t, HashArgs(math.NaN()), HashArgs(0.0))
+	require.NotEqual(t, HashArgs(int32(1)), HashArgs(int64(1)))
+	require.NotEqual(t, HashArgs(nil), HashArgs("nil"))
+}
+
+func TestWithoutSingleflight(t *testing.T) {
+	m, err := New(WithMaxSize(10), WithoutSingleflight())
+	require.Nil(t, err)
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return "a", nil
+	}
+	value, _, cached := m.Do("key", fn)
+	require.False(t, cached)
+	require.Equal(t, "a", value)
+
+	value, _, cached = m.Do("key", fn)
+	require.True(t, cached)
+	require.Equal(t, "a", value)
+	require.Equal(t, 1, calls)
+
+	// errors are not cached
+	_, err, _ = m.Do("failing", func() (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	require.NotNil(t, err)
+	_, _, cached = m.Do("failing", fn)
+	require.False(t, cached)
+}
+
+func BenchmarkDo(b *testing.B) {
+	for name, options := range map[string][]MemoizeOption{
+		"Singleflight":        {WithMaxSize(1000)},
+		"WithoutSingleflight": {WithMaxSize(1000), WithoutSingleflight()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			m, err := New(options...)
+			require.Nil(b, err)
+			keys := make([]string, 2000)
+			for i := range keys {
+				keys[i] = strconv.Itoa(i)
+			}
+			fn := func() (interface{}, error) {
+				return 1, nil
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_, _, _ = m.Do(keys[i%len(keys)], fn)
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package memoize
+
+import (
+	"sync"
+	"time"
+
+	singleflight "github.com/projectdiscovery/utils/memoize/simpleflight"
+)
+
+// Periodic returns a memoized version of the zero argument fn whose result is
+// reused until ttl elapsed since it was computed ex: configuration fetched from
+// a remote, once expired the next call computes it again while concurrent calls
+// wait for that single computation, a non positive ttl never expires the result
+//
+// Errors are not cached i.e the previous result is not served once expired
+// and fn is called again on next call
+//
+//	getConfig := memoize.Periodic(5*time.Minute, fetchRemoteConfig)
+//	cfg, err := getConfig()
+func Periodic[R any](ttl time.Duration, fn func() (R, error)) func() (R, error) {
+	var (
+		mu      sync.RWMutex
+		value   R
+		valid   bool
+		expires time.Time
+		group   singleflight.Group[struct{}]
+	)
+	return func() (R, error) {
+		mu.RLock()
+		if valid && (ttl <= 0 || time.Now().Before(expires)) {
+			v := value
+			mu.RUnlock()
+			return v, nil
+		}
+		mu.RUnlock()
+
+		v, err, _ := group.Do(struct{}{}, func() (interface{}, error) {
+			r, err := fn()
+			if err != nil {
+				return r, err
+			}
+			mu.Lock()
+			value, valid, expires = r, true, time.Now().Add(ttl)
+			mu.Unlock()
+			return r, nil
+		})
+		r, _ := v.(R)
+		return r, err
+	}
+}
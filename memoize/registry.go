@@ -0,0 +1,93 @@
+package memoize
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	// registry holds the invalidate functions of memoized functions by name
+	registry = map[string][]func(){}
+)
+
+// Register registers the invalidate function of a memoized function under
+// given name, it is called by code generated with WithRegistration in init
+// with names qualified by the import path of the generated package ex:
+// "example.com/app/memo.Fetch", functions registered more than once under
+// the same name are invalidated together
+func Register(name string, invalidate func()) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[name] = append(registry[name], invalidate)
+}
+
+// Registered returns the sorted names of registered memoized functions
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// InvalidateFunc clears the cached results of the memoized function registered
+// under given name and returns false if there is none
+//
+//	memoize.InvalidateFunc("example.com/app/memo.Fetch")
+func InvalidateFunc(name string) bool {
+	registryMu.RLock()
+	invalidates := registry[name]
+	registryMu.RUnlock()
+
+	for _, invalidate := range invalidates {
+		invalidate()
+	}
+	return len(invalidates) > 0
+}
+
+// CallerPackagePath returns the import path of the package of the calling function
+// ex: to qualify names given to Register
+func CallerPackagePath() string {
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return ""
+	}
+	f := runtime.FuncForPC(pc)
+	if f == nil {
+		return ""
+	}
+	return packagePath(f.Name())
+}
+
+// packagePath returns the import path of the package of given function name
+// ex: "example.com/app/memo.init.0" returns "example.com/app/memo"
+func packagePath(funcName string) string {
+	lastSlash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[lastSlash+1:], "."); dot >= 0 {
+		funcName = funcName[:lastSlash+1+dot]
+	}
+	// dots of the last path element are escaped in symbol names
+	return strings.ReplaceAll(funcName, "%2e", ".")
+}
+
+// InvalidateAll clears the cached results of all registered memoized functions
+func InvalidateAll() {
+	registryMu.RLock()
+	var invalidates []func()
+	for _, fns := range registry {
+		invalidates = append(invalidates, fns...)
+	}
+	registryMu.RUnlock()
+
+	for _, invalidate := range invalidates {
+		invalidate()
+	}
+}
@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+// PrefetchCalls counts the calls of TestPrefetch
+var PrefetchCalls atomic.Int64
+
+// @memo async
+func TestPrefetch(a string) (string, error) {
+	PrefetchCalls.Add(1)
+	return strings.ToUpper(a), nil
+}
+
+// @memo async
+func TestPrefetchNoArgs() string {
+	return "a"
+}
+
+// @memo async scope=context
+func TestPrefetchContext(ctx context.Context, a string, b ...int) (string, int) {
+	return a, len(b)
+}
@@ -0,0 +1,6 @@
+package tests
+
+// @memo
+func TestWithBlankResult(a string) (_ int, err error) {
+	return len(a), nil
+}
@@ -0,0 +1,19 @@
+package tests
+
+// @memo cacheif=NotEmpty
+func TestWithCacheIf(a string) ([]string, error) {
+	return []string{a}, nil
+}
+
+// @memo cacheif=Ready
+func TestWithCacheIfNoArgs() bool {
+	return true
+}
+
+func NotEmpty(v []string, err error) bool {
+	return len(v) > 0
+}
+
+func Ready(v bool) bool {
+	return v
+}
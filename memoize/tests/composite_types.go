@@ -0,0 +1,14 @@
+package tests
+
+// @memo
+func TestWithStructParam(opts struct {
+	Name    string `json:"name"`
+	Retries int
+}) string {
+	return opts.Name
+}
+
+// @memo
+func TestWithInterfaceParam(s interface{ String() string }) (interface{ Len() int }, error) {
+	return nil, nil
+}
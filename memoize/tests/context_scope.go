@@ -0,0 +1,17 @@
+package tests
+
+import "context"
+
+// @memo scope=context
+func TestWithContextScope(a string) (string, error) {
+	return a, nil
+}
+
+// @memo scope=context
+func TestWithContextParam(ctx context.Context, a int) int {
+	return a
+}
+
+// @memo scope=context
+func TestWithContextNoArgs() {
+}
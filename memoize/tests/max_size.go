@@ -0,0 +1,11 @@
+package tests
+
+// @memo maxsize=100
+func TestWithMaxSize(a string, b int) (string, error) {
+	return a, nil
+}
+
+// @memo maxsize=1
+func TestWithMaxSizeNoArgs() string {
+	return "a"
+}
@@ -0,0 +1,6 @@
+package tests
+
+// @memo
+func TestWithNamedMultipleReturnValues(a string) (x, y int, err error) {
+	return len(a), 2 * len(a), nil
+}
@@ -0,0 +1,21 @@
+package tests
+
+import (
+	. "net/url"
+	stdtime "time"
+)
+
+// @memo
+func TestWithNamedImport(d stdtime.Duration) (stdtime.Time, error) {
+	return stdtime.Now().Add(d), nil
+}
+
+// @memo
+func TestWithDotImport(raw string) (*URL, error) {
+	return Parse(raw)
+}
+
+// @memo
+func TestWithDotImportAndSourceTypes(c Config, u *URL) (*Config, error) {
+	return &c, nil
+}
@@ -0,0 +1,6 @@
+package tests
+
+// @memo
+func TestWithNoArgs() (string, int) {
+	return "a", 1
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// RegisteredCalls and RegisteredOtherCalls count the calls of
+// TestRegistered and TestRegisteredOther
+var RegisteredCalls, RegisteredOtherCalls atomic.Int64
+
+// @memo
+func TestRegistered(a string) (string, error) {
+	RegisteredCalls.Add(1)
+	return a, nil
+}
+
+// @memo
+func TestRegisteredOther(a string) string {
+	RegisteredOtherCalls.Add(1)
+	return a
+}
+
+// @memo maxsize=10
+func TestRegisteredMaxSize(a string, b int) string {
+	return a
+}
+
+// @memo typed
+func TestRegisteredTyped(a int) int {
+	return a
+}
+
+// @memo
+func TestRegisteredOnce() string {
+	return "a"
+}
+
+// @memo scope=context
+func TestRegisteredContext(ctx context.Context, a string) string {
+	return a
+}
@@ -0,0 +1,28 @@
+package tests
+
+import "strconv"
+
+// @memo
+func TestRuntime(a int) (string, error) {
+	return strconv.Itoa(a), nil
+}
+
+// @memo maxsize=10
+func TestRuntimeMultipleArgs(a string, b int) int {
+	return len(a) + b
+}
+
+// @memo metrics
+func TestRuntimeFallback(a string) string {
+	return a
+}
+
+// Query is a parameter type that is not comparable
+type Query struct {
+	Hosts []string
+}
+
+// @memo
+func TestRuntimeQuery(q Query) (int, error) {
+	return len(q.Hosts), nil
+}
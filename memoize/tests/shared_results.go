@@ -0,0 +1,13 @@
+package tests
+
+// @memo
+func TestWithChanResult(a string) <-chan string {
+	ch := make(chan string, 1)
+	ch <- a
+	return ch
+}
+
+// @memo
+func TestWithFuncResult(a string) (func() string, error) {
+	return func() string { return a }, nil
+}
@@ -0,0 +1,6 @@
+package tests
+
+// @memo
+func TestWithSingleArg(a string) string {
+	return a
+}
@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TypedSlowCalls counts the calls of TestTypedSlow
+var TypedSlowCalls atomic.Int64
+
+// @memo typed
+func TestTyped(a int) (string, error) {
+	return strconv.Itoa(a), nil
+}
+
+// @memo typed metrics
+func TestTypedMultipleArgs(a string, b int) int {
+	return len(a) + b
+}
+
+// @memo typed
+func TestTypedSlow(a int) int {
+	TypedSlowCalls.Add(1)
+	time.Sleep(50 * time.Millisecond)
+	return a
+}
@@ -0,0 +1,10 @@
+package tests
+
+type Config struct {
+	Name string
+}
+
+// @memo
+func TestWithSourceTypes(c Config, names ...string) (*Config, map[string][]Config) {
+	return &c, map[string][]Config{c.Name: {c}}
+}
@@ -0,0 +1,102 @@
+package memoize
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithTypeCheck type-checks the package of the source file with go/types
+// before generation and fails with the type errors found in signatures of
+// tagged functions ex: undefined types, instead of generating code that
+// does not compile
+//
+// The package is made of the source and the other go files of its directory
+// matching the build context, imported packages are loaded from source so
+// this is noticeably slower than the default syntactic parsing
+func WithTypeCheck() SrcOption {
+	return func(f *FileData) {
+		f.TypeCheck = true
+	}
+}
+
+// typeCheck type-checks the package of given file and returns the errors
+// located in signatures of functions tagged with the @memo directive
+func typeCheck(fset *token.FileSet, node *ast.File, sourcePath string, caseInsensitive bool) error {
+	var signatures []*ast.FuncDecl
+	for _, decl := range node.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && isTagged(funcDecl, caseInsensitive) {
+			signatures = append(signatures, funcDecl)
+		}
+	}
+	if len(signatures) == 0 {
+		return nil
+	}
+
+	var errs []error
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			typeErr, ok := err.(types.Error)
+			if !ok {
+				return
+			}
+			for _, funcDecl := range signatures {
+				if typeErr.Pos >= funcDecl.Type.Pos() && typeErr.Pos < funcDecl.Type.End() {
+					errs = append(errs, fmt.Errorf("%s: %s", funcDecl.Name.Name, typeErr))
+					return
+				}
+			}
+		},
+	}
+	_, _ = conf.Check(node.Name.Name, fset, packageFiles(fset, node, sourcePath), nil)
+	return errors.Join(errs...)
+}
+
+// packageFiles returns given file along with the other files of its
+// package located in the same directory that match the build context
+func packageFiles(fset *token.FileSet, node *ast.File, sourcePath string) []*ast.File {
+	files := []*ast.File{node}
+
+	dir := filepath.Dir(sourcePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") || name == filepath.Base(sourcePath) {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil || file.Name.Name != node.Name.Name {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// isTagged checks if the doc of given function contains the @memo directive
+func isTagged(funcDecl *ast.FuncDecl, caseInsensitive bool) bool {
+	if funcDecl.Doc == nil {
+		return false
+	}
+	for _, comment := range funcDecl.Doc.List {
+		if _, ok := parseDirective(comment.Text, caseInsensitive); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package memoize
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// Wrap returns a memoized version of fn whose results are cached by argument
+// errors are not cached and fn is called again on next call
+//
+// The cache key is prefixed with identity of fn so that different functions
+// wrapped with the same Memoizer do not share entries for equal arguments.
+// Identity is derived from the code pointer of fn, hence closures created from
+// the same function literal share identity irrespective of captured state and
+// must be wrapped with distinct Memoizers, the argument is part of the key
+// through HashArgs so struct arguments formatting the same are told apart
+// and arguments do not need to be comparable ex: structs with slice fields
+//
+//	resolve := memoize.Wrap(m, net.LookupHost)
+//	addrs, err := resolve("example.com")
+func Wrap[K any, V any](m *Memoizer, fn func(K) (V, error)) func(K) (V, error) {
+	prefix := funcIdentity(fn)
+	return func(arg K) (V, error) {
+		value, err, _ := m.Do(prefix+":"+HashArgs(arg), func() (interface{}, error) {
+			return fn(arg)
+		})
+		v, _ := value.(V)
+		return v, err
+	}
+}
+
+// funcIdentity returns a string identifying the code of given function
+func funcIdentity(fn any) string {
+	pc := reflect.ValueOf(fn).Pointer()
+	if f := runtime.FuncForPC(pc); f != nil {
+		return fmt.Sprintf("%s@%x", f.Name(), pc)
+	}
+	return fmt.Sprintf("%x", pc)
+}